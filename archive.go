@@ -0,0 +1,186 @@
+package ignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// readLinkFS is implemented by file systems that can report symlink targets,
+// such as os.DirFS. It matches fs.ReadLinkFS without requiring a newer Go.
+type readLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// TarFilter writes every entry under root in fsys that m does not ignore to
+// tw, the typical "build a release tarball that respects .gitignore" step.
+// Entry names are relative to root; root itself is not written. Directories
+// become tar.TypeDir entries and ignored directories are pruned without being
+// read. Symlinks are stored as tar.TypeSymlink when fsys implements
+// ReadLink(name string) (string, error) and as regular files otherwise.
+//
+// The first error from fsys, m, or tw aborts the walk and is returned. TarFilter
+// does not close tw.
+func TarFilter(fsys fs.FS, root string, m *Matcher, tw *tar.Writer) error {
+	return WalkFS(fsys, root, m, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if err := writeTarEntry(fsys, path, fsRel(root, path), d, tw); err != nil {
+			return fmt.Errorf("ignore: archiving %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func writeTarEntry(fsys fs.FS, path, name string, d fs.DirEntry, tw *tar.Writer) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if d.Type()&fs.ModeSymlink != 0 {
+		if rl, ok := fsys.(readLinkFS); ok {
+			if link, err = rl.ReadLink(path); err != nil {
+				return err
+			}
+		} else if info, err = fs.Stat(fsys, path); err != nil { // store the target's content
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// ZipFilterOptions controls how ZipFilterWithOptions writes entries. The zero
+// value matches ZipFilter: Deflate at the default level, with modification
+// times.
+type ZipFilterOptions struct {
+	// Level is the flate compression level, between flate.HuffmanOnly and
+	// flate.BestCompression. Zero keeps the Writer's Deflate compressor;
+	// any other value registers a new one on the Writer.
+	Level int
+
+	// Store writes files uncompressed and ignores Level.
+	Store bool
+
+	// OmitModTime leaves entry timestamps unset so the archive does not
+	// depend on when the files were last touched.
+	OmitModTime bool
+}
+
+// ZipFilter is the archive/zip counterpart of TarFilter: it writes every entry
+// under root in fsys that m does not ignore to zw, with names relative to
+// root, much like git archive --format=zip. Directories become explicit
+// "name/" entries, files are compressed with Deflate, and modification times
+// are kept. Symlinks are handled as in TarFilter. ZipFilter does not close zw.
+func ZipFilter(fsys fs.FS, root string, m *Matcher, zw *zip.Writer) error {
+	return ZipFilterWithOptions(fsys, root, m, zw, ZipFilterOptions{})
+}
+
+// ZipFilterWithOptions is ZipFilter with control over compression and
+// timestamps.
+func ZipFilterWithOptions(fsys fs.FS, root string, m *Matcher, zw *zip.Writer, opts ZipFilterOptions) error {
+	if opts.Level != 0 && !opts.Store {
+		if opts.Level < flate.HuffmanOnly || opts.Level > flate.BestCompression {
+			return fmt.Errorf("ignore: invalid zip compression level %d", opts.Level)
+		}
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, opts.Level)
+		})
+	}
+
+	return WalkFS(fsys, root, m, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if err := writeZipEntry(fsys, path, fsRel(root, path), d, zw, opts); err != nil {
+			return fmt.Errorf("ignore: archiving %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func writeZipEntry(fsys fs.FS, path, name string, d fs.DirEntry, zw *zip.Writer, opts ZipFilterOptions) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+
+	var link string
+	isLink := d.Type()&fs.ModeSymlink != 0
+	if isLink {
+		if rl, ok := fsys.(readLinkFS); ok {
+			if link, err = rl.ReadLink(path); err != nil {
+				return err
+			}
+		} else if info, err = fs.Stat(fsys, path); err != nil { // store the target's content
+			return err
+		}
+		isLink = link != ""
+	}
+
+	hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	hdr.SetMode(info.Mode())
+	if !opts.OmitModTime {
+		hdr.Modified = info.ModTime()
+	}
+	if opts.Store || info.IsDir() || isLink {
+		hdr.Method = zip.Store
+	}
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		return nil
+	case isLink:
+		_, err = io.WriteString(w, link)
+		return err
+	}
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = io.Copy(w, f)
+	return err
+}
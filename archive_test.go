@@ -0,0 +1,188 @@
+package ignore
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// TarFilter
+// ---------------------------------------------------------------------------
+
+// readTar returns the entries of a tar stream as name → content, with
+// directories and symlinks mapped to "<dir>" and "-> target".
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	entries := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		require.NoError(t, err)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			entries[hdr.Name] = "<dir>"
+		case tar.TypeSymlink:
+			entries[hdr.Name] = "-> " + hdr.Linkname
+		default:
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			entries[hdr.Name] = string(data)
+		}
+	}
+}
+
+func TestTarFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proj/main.go":        {Data: []byte("package main")},
+		"proj/debug.log":      {Data: []byte("noise")},
+		"proj/build/out.bin":  {Data: []byte("binary")},
+		"proj/src/lib.go":     {Data: []byte("package src")},
+		"proj/src/build/x.go": {Data: []byte("package build")},
+	}
+
+	m, err := NewMatcher([]string{"*.log", "/build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, TarFilter(fsys, "proj", m, tw))
+	require.NoError(t, tw.Close())
+
+	assert.Equal(t, map[string]string{
+		"main.go":        "package main",
+		"src/":           "<dir>",
+		"src/lib.go":     "package src",
+		"src/build/":     "<dir>",
+		"src/build/x.go": "package build",
+	}, readTar(t, &buf))
+}
+
+func TestTarFilterSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "target.txt"), []byte("hello"), 0o644))
+	if err := os.Symlink("target.txt", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	// os.DirFS can read link targets, so the link is preserved.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, TarFilter(os.DirFS(dir), ".", m, tw))
+	require.NoError(t, tw.Close())
+	assert.Equal(t, map[string]string{"link.txt": "-> target.txt", "target.txt": "hello"}, readTar(t, &buf))
+
+	// Without ReadLink the link is stored as a copy of its target.
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	require.NoError(t, TarFilter(noReadLinkFS{os.DirFS(dir)}, ".", m, tw))
+	require.NoError(t, tw.Close())
+	assert.Equal(t, map[string]string{"link.txt": "hello", "target.txt": "hello"}, readTar(t, &buf))
+}
+
+// noReadLinkFS hides every method of the wrapped FS except Open.
+type noReadLinkFS struct{ fsys fs.FS }
+
+func (f noReadLinkFS) Open(name string) (fs.File, error) { return f.fsys.Open(name) }
+
+func TestTarFilterErrors(t *testing.T) {
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	err = TarFilter(fstest.MapFS{}, "missing", m, tar.NewWriter(io.Discard))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// ---------------------------------------------------------------------------
+// ZipFilter
+// ---------------------------------------------------------------------------
+
+// zipTestFS is a small tree with one ignored file and one ignored directory.
+func zipTestFS(mtime time.Time) fstest.MapFS {
+	return fstest.MapFS{
+		"main.go":       {Data: []byte("package main"), ModTime: mtime},
+		"debug.log":     {Data: []byte("noise"), ModTime: mtime},
+		"build/out.bin": {Data: []byte("binary"), ModTime: mtime},
+		"src":           {Mode: fs.ModeDir | 0o755, ModTime: mtime},
+		"src/lib.go":    {Data: []byte("package src"), ModTime: mtime},
+	}
+}
+
+// writeZip runs ZipFilterWithOptions over fsys and returns the archive.
+func writeZip(t *testing.T, fsys fs.FS, opts ZipFilterOptions) *zip.Reader {
+	t.Helper()
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	require.NoError(t, ZipFilterWithOptions(fsys, ".", m, zw, opts))
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func TestZipFilter(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	zr := writeZip(t, zipTestFS(mtime), ZipFilterOptions{})
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		assert.True(t, f.Modified.Equal(mtime), "%s: modification time should be kept", f.Name)
+		if f.Name == "src/" {
+			assert.True(t, f.Mode().IsDir())
+			continue
+		}
+		assert.Equal(t, zip.Deflate, f.Method, f.Name)
+	}
+	assert.Equal(t, []string{"main.go", "src/", "src/lib.go"}, names)
+
+	rc, err := zr.Open("src/lib.go")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "package src", string(data))
+}
+
+func TestZipFilterWithOptions(t *testing.T) {
+	zr := writeZip(t, zipTestFS(time.Now()), ZipFilterOptions{Store: true, OmitModTime: true})
+	for _, f := range zr.File {
+		assert.Equal(t, zip.Store, f.Method, f.Name)
+		// An unset MS-DOS timestamp reads back as a date before the 1980 epoch.
+		assert.Less(t, f.Modified.Year(), 1981, "%s: modification time should be omitted", f.Name)
+	}
+
+	zr = writeZip(t, zipTestFS(time.Now()), ZipFilterOptions{Level: flate.BestCompression})
+	assert.Len(t, zr.File, 3)
+
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	err = ZipFilterWithOptions(fstest.MapFS{}, ".", m, zip.NewWriter(io.Discard), ZipFilterOptions{Level: 42})
+	assert.ErrorContains(t, err, "compression level")
+}
@@ -0,0 +1,230 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NewMatcherFromCargoExclude compiles the package.exclude array of the
+// Cargo.toml at cargoTomlPath. Cargo reads these entries with gitignore
+// semantics, so the Matcher reports exactly the paths cargo package leaves
+// out because of them. A manifest without an exclude field yields a Matcher
+// that ignores nothing.
+func NewMatcherFromCargoExclude(cargoTomlPath string) (*Matcher, error) {
+	exclude, _, err := readCargoArray(cargoTomlPath, "exclude")
+	if err != nil {
+		return nil, err
+	}
+	return NewMatcher(exclude)
+}
+
+// NewMatcherFromCargoInclude compiles the package.include array of the
+// Cargo.toml at cargoTomlPath with inverted semantics: a path is ignored
+// unless an include entry, or one of its parent directories, matches it.
+// Negated entries ("!src/tests") carve paths back out. A manifest without an
+// include field yields a Matcher that ignores nothing, as cargo then packages
+// everything not excluded.
+//
+// The entries are rewritten into gitignore patterns behind a leading "*", so
+// the Matcher answers correctly for file paths, but directories on the way
+// to an included file (such as "src" for "src/**/*.rs") are reported as
+// ignored. Match file paths rather than pruning a walk with it.
+func NewMatcherFromCargoInclude(cargoTomlPath string) (*Matcher, error) {
+	include, ok, err := readCargoArray(cargoTomlPath, "include")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return NewMatcher(nil)
+	}
+	return NewMatcher(invertIncludePatterns(include))
+}
+
+// invertIncludePatterns turns "keep only these" patterns into gitignore
+// patterns that ignore everything else. Each entry also covers everything
+// below a matching directory via an extra "/**" pattern.
+func invertIncludePatterns(include []string) []string {
+	patterns := []string{"*"}
+	for _, p := range include {
+		neg := IsNegationPattern(p)
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "!"), "/")
+		if p == "" {
+			continue
+		}
+		prefix := "!" // an include re-includes; a negated include ignores
+		if neg {
+			prefix = ""
+		}
+		patterns = append(patterns, prefix+p, prefix+p+"/**")
+	}
+	return patterns
+}
+
+// readCargoArray reads the string array key from the [package] table of the
+// manifest at path. ok is false when the key is absent.
+func readCargoArray(path, key string) (values []string, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	values, ok, err = cargoPackageArray(string(data), key)
+	if err != nil {
+		return nil, false, fmt.Errorf("ignore: parsing %s: package.%s: %w", path, key, err)
+	}
+	return values, ok, nil
+}
+
+// cargoPackageArray finds key in the [package] table of a Cargo.toml and
+// parses its value as an array of strings. This is deliberately not a TOML
+// parser: it understands table headers, "key = [...]" assignments that may
+// span lines, basic and literal strings, and comments, which is all a
+// manifest's include and exclude fields use. The values of other keys are
+// skipped as a whole, so the lines of a multi-line array or string are never
+// mistaken for assignments or table headers.
+func cargoPackageArray(src, key string) ([]string, bool, error) {
+	table := ""
+	for offset := 0; offset < len(src); {
+		line, _, _ := strings.Cut(src[offset:], "\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "["):
+			header, _, _ := strings.Cut(trimmed, "]")
+			table = strings.TrimSpace(strings.TrimLeft(header, "["))
+		default:
+			k, _, found := strings.Cut(line, "=")
+			if !found {
+				break
+			}
+			value := src[offset+len(k)+1:]
+			if table == "package" && strings.Trim(strings.TrimSpace(k), `"'`) == key {
+				values, err := parseTOMLStringArray(value)
+				return values, true, err
+			}
+			rest, err := skipTOMLValue(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("value of %s: %w", strings.TrimSpace(k), err)
+			}
+			// Resume on the line where the value ends; what follows it there
+			// can only be a comment.
+			offset = len(src) - len(rest)
+			line, _, _ = strings.Cut(rest, "\n")
+		}
+		offset += len(line) + 1
+	}
+	return nil, false, nil
+}
+
+// skipTOMLValue returns what follows the value at the start of s. Arrays and
+// multi-line strings may span lines; any other value ends with its line.
+func skipTOMLValue(s string) (string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''") {
+		end := tomlStringEnd(s)
+		if end < 0 {
+			return "", errors.New("unterminated string")
+		}
+		return s[end+1:], nil
+	}
+	if strings.HasPrefix(s, "[") {
+		return skipTOMLArray(s)
+	}
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[i:], nil
+	}
+	return "", nil
+}
+
+// skipTOMLArray returns what follows the array at the start of s, which may
+// nest and contain strings and comments.
+func skipTOMLArray(s string) (string, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return s[i+1:], nil
+			}
+		case '#':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return "", errors.New("unterminated array")
+			}
+			i += end
+		case '"', '\'':
+			end := tomlStringEnd(s[i:])
+			if end < 0 {
+				return "", errors.New("unterminated string")
+			}
+			i += end
+		}
+	}
+	return "", errors.New("unterminated array")
+}
+
+// tomlStringEnd returns the index of the last quote of the basic, literal,
+// or multi-line string at the start of s, or -1 if it is not terminated.
+func tomlStringEnd(s string) int {
+	if delim := s[:min(3, len(s))]; delim == `"""` || delim == "'''" {
+		if end := strings.Index(s[3:], delim); end >= 0 {
+			return 3 + end + 2
+		}
+		return -1
+	}
+	if s[0] == '"' {
+		return closingQuote(s)
+	}
+	if end := strings.IndexAny(s[1:], "'\n"); end >= 0 && s[1+end] == '\'' {
+		return 1 + end
+	}
+	return -1
+}
+
+// parseTOMLStringArray parses the array of strings at the start of s,
+// ignoring anything after its closing bracket.
+func parseTOMLStringArray(s string) ([]string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if !strings.HasPrefix(s, "[") {
+		return nil, errors.New("expected an array of strings")
+	}
+	s = s[1:]
+
+	values := []string{}
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,")
+		if s == "" {
+			return nil, errors.New("unterminated array")
+		}
+		switch s[0] {
+		case ']':
+			return values, nil
+		case '#':
+			_, s, _ = strings.Cut(s, "\n")
+		case '\'': // literal string: no escapes
+			end := strings.IndexAny(s[1:], "'\n")
+			if end < 0 || s[1+end] != '\'' {
+				return nil, errors.New("unterminated string")
+			}
+			values = append(values, s[1:1+end])
+			s = s[2+end:]
+		case '"':
+			end := closingQuote(s)
+			if end < 0 {
+				return nil, errors.New("unterminated string")
+			}
+			v, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", s[:end+1])
+			}
+			values = append(values, v)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in array of strings", s[0])
+		}
+	}
+}
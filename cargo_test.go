@@ -0,0 +1,122 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCargoToml = `[package]
+name = "demo"
+authors = ["A <a@example.com>"]
+exclude = [
+    "/ci",     # CI scripts
+    '*.png',
+    "tests/fixtures/",
+]
+include = ["src/", "Cargo.toml", "!src/bin/dev.rs"]
+
+[dependencies]
+exclude = ["not-a-package-field"]
+`
+
+// writeCargoToml writes content to a Cargo.toml in a temporary directory.
+func writeCargoToml(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Cargo.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromCargoExclude / NewMatcherFromCargoInclude
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromCargoExclude(t *testing.T) {
+	m, err := NewMatcherFromCargoExclude(writeCargoToml(t, testCargoToml))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assertStringSliceEqual(t, m.Patterns(), []string{"/ci", "*.png", "tests/fixtures/"})
+	assert.True(t, m.Match("ci/build.sh"))
+	assert.True(t, m.Match("docs/logo.png"))
+	assert.True(t, m.Match("tests/fixtures/a.json"))
+	assert.False(t, m.Match("src/ci/mod.rs"))
+	assert.False(t, m.Match("src/lib.rs"))
+}
+
+func TestNewMatcherFromCargoInclude(t *testing.T) {
+	m, err := NewMatcherFromCargoInclude(writeCargoToml(t, testCargoToml))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.False(t, m.Match("src/lib.rs"))
+	assert.False(t, m.Match("src/nested/mod.rs"))
+	assert.False(t, m.Match("Cargo.toml"))
+	assert.True(t, m.Match("src/bin/dev.rs"), "negated include entries are ignored")
+	assert.True(t, m.Match("README.md"))
+	assert.True(t, m.Match("tests/it.rs"))
+}
+
+func TestCargoArrayElementsAreNotKeys(t *testing.T) {
+	// Lines inside another key's array or multi-line string must not be
+	// read as assignments or table headers.
+	path := writeCargoToml(t, `[package]
+name = "demo"
+exclude = [
+    "include = x",
+    "[dependencies]",
+    "*.png",
+]
+description = """
+include = ["nope"]
+[workspace]
+"""
+keywords = [ # a comment with include = ["nope"]
+    'a]b', "c\"]",
+]
+`)
+
+	m, err := NewMatcherFromCargoExclude(path)
+	require.NoError(t, err)
+	assertStringSliceEqual(t, m.Patterns(), []string{"include = x", "[dependencies]", "*.png"})
+	require.NoError(t, m.Close())
+
+	m, err = NewMatcherFromCargoInclude(path)
+	require.NoError(t, err)
+	assert.False(t, m.Match("README.md"), "no include key: the array element is not one")
+	require.NoError(t, m.Close())
+}
+
+func TestCargoMissingFields(t *testing.T) {
+	path := writeCargoToml(t, "[package]\nname = \"demo\"\n")
+
+	m, err := NewMatcherFromCargoExclude(path)
+	require.NoError(t, err)
+	assert.False(t, m.Match("anything.rs"))
+	require.NoError(t, m.Close())
+
+	m, err = NewMatcherFromCargoInclude(path)
+	require.NoError(t, err)
+	assert.False(t, m.Match("anything.rs"), "no include field means everything is packaged")
+	require.NoError(t, m.Close())
+}
+
+func TestCargoErrors(t *testing.T) {
+	_, err := NewMatcherFromCargoExclude(filepath.Join(t.TempDir(), "Cargo.toml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, content := range []string{
+		"[package]\nexclude = \"*.png\"\n",
+		"[package]\nexclude = [\"*.png\"\n",
+		"[package]\nexclude = ['unterminated]\n",
+		"[package]\nexclude = [1, 2]\n",
+		"[package]\nkeywords = [\"a\"\nexclude = []\n",
+	} {
+		_, err := NewMatcherFromCargoExclude(writeCargoToml(t, content))
+		assert.ErrorContains(t, err, "package.exclude", content)
+	}
+}
@@ -0,0 +1,71 @@
+package ignore
+
+import "strings"
+
+// CaseFoldMatcher makes any IMatcher case-insensitive by lower-casing every
+// path before delegating. It is for Matchers the caller did not construct
+// and so cannot rebuild with WithCaseInsensitive. Only paths are folded, so
+// the wrapped matcher's patterns must already be lower case: "*.log"
+// matches "DEBUG.LOG" through the wrapper, but "*.LOG" matches nothing.
+//
+// Filter and FilterParallel match the folded paths but return the caller's
+// original strings. Concurrency follows the wrapped matcher; Close closes
+// it.
+type CaseFoldMatcher struct {
+	IMatcher
+}
+
+// NewCaseFoldMatcher returns m wrapped in a CaseFoldMatcher. The wrapper
+// takes ownership of m.
+func NewCaseFoldMatcher(m IMatcher) IMatcher {
+	return &CaseFoldMatcher{IMatcher: m}
+}
+
+// Match reports whether the file at path is ignored, ignoring case.
+func (c *CaseFoldMatcher) Match(path string) bool {
+	return c.IMatcher.Match(strings.ToLower(path))
+}
+
+// MatchDir is like Match for a directory path.
+func (c *CaseFoldMatcher) MatchDir(path string) bool {
+	return c.IMatcher.MatchDir(strings.ToLower(path))
+}
+
+// MatchResult reports whether path is ignored, ignoring case, and surfaces
+// any error.
+func (c *CaseFoldMatcher) MatchResult(path string, isDir bool) (bool, error) {
+	return c.IMatcher.MatchResult(strings.ToLower(path), isDir)
+}
+
+// Filter returns the paths that are NOT ignored, ignoring case, with their
+// original casing and in input order.
+func (c *CaseFoldMatcher) Filter(paths []string) ([]string, error) {
+	return c.filter(paths, c.IMatcher.Filter)
+}
+
+// FilterParallel is like Filter but delegates to the wrapped matcher's
+// FilterParallel.
+func (c *CaseFoldMatcher) FilterParallel(paths []string) ([]string, error) {
+	return c.filter(paths, c.IMatcher.FilterParallel)
+}
+
+// filter runs filter over the folded paths and maps the kept entries back to
+// the caller's strings.
+func (c *CaseFoldMatcher) filter(paths []string, filter func([]string) ([]string, error)) ([]string, error) {
+	folded := make([]string, len(paths))
+	for i, p := range paths {
+		folded[i] = strings.ToLower(p)
+	}
+	kept, err := filter(folded)
+	if err != nil || len(kept) == 0 {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(kept))
+	for i, keep := range keptMask(folded, kept) {
+		if keep {
+			out = append(out, paths[i])
+		}
+	}
+	return out, nil
+}
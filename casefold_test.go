@@ -0,0 +1,48 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// CaseFoldMatcher
+// ---------------------------------------------------------------------------
+
+func TestCaseFoldMatcher(t *testing.T) {
+	inner, err := NewMatcher([]string{"*.log", "build/", "!keep.log"})
+	require.NoError(t, err)
+	m := NewCaseFoldMatcher(inner)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("DEBUG.LOG"))
+	assert.True(t, m.MatchDir("Build"))
+	assert.False(t, m.Match("Keep.Log"))
+	ignored, err := m.MatchResult("Src/App.Log", false)
+	require.NoError(t, err)
+	assert.True(t, ignored)
+
+	paths := []string{"Main.go", "Debug.LOG", "BUILD/", "KEEP.log", "main.go"}
+	want := []string{"Main.go", "KEEP.log", "main.go"}
+	kept, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, kept, "original casing is preserved")
+	kept, err = m.FilterParallel(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, kept)
+
+	kept, err = m.Filter([]string{"A.LOG"})
+	require.NoError(t, err)
+	assert.Nil(t, kept)
+}
+
+func TestCaseFoldMatcherClosesInner(t *testing.T) {
+	inner, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+
+	require.NoError(t, NewCaseFoldMatcher(inner).Close())
+	_, err = inner.MatchResultCode("a.log", false)
+	assert.ErrorIs(t, err, ErrMatcherClosed)
+}
@@ -0,0 +1,84 @@
+package ignore
+
+import "fmt"
+
+// ChunkStrategy splits the paths of a FilterParallel call into at most n
+// chunks. FilterParallel asks for several chunks per worker and its workers
+// pull them from a shared queue, so a worker that finishes early takes more.
+// The chunks must be consecutive sub-slices of paths that together cover all
+// of it, in order, since the results are merged by concatenation; empty
+// chunks are dropped. A strategy that follows these rules only affects how
+// evenly work is spread, never which paths are kept. One that breaks them
+// makes every FilterParallel call fail with an error.
+type ChunkStrategy func(paths []string, n int) [][]string
+
+// ChunkStrategyUniform gives every chunk the same number of paths,
+// ceil(len(paths)/n). It is the default. n below 1 is treated as 1.
+func ChunkStrategyUniform(paths []string, n int) [][]string {
+	n = max(n, 1)
+	size := (len(paths) + n - 1) / n
+	chunks := make([][]string, 0, n)
+	for i := 0; i < len(paths); i += size {
+		chunks = append(chunks, paths[i:min(i+size, len(paths))])
+	}
+	return chunks
+}
+
+// ChunkStrategyDynamic balances chunks by byte count instead of path count,
+// so a run of short paths lands in a larger chunk and a run of long, deeply
+// nested paths in a smaller one. Matching cost grows with path length, so
+// this evens out workers on lists that mix the two. As with
+// ChunkStrategyUniform, n below 1 is treated as 1.
+func ChunkStrategyDynamic(paths []string, n int) [][]string {
+	n = max(n, 1)
+	total := 0
+	for _, p := range paths {
+		total += len(p) + 1 // +1 for the NUL separator sent to WASM
+	}
+
+	chunks := make([][]string, 0, n)
+	start, sent := 0, 0
+	for i := 0; i < len(paths)-1 && len(chunks) < n-1; i++ {
+		sent += len(paths[i]) + 1
+		// Cut after paths[i] once the chunk reaches its share of the bytes,
+		// or earlier if taking paths[i+1] would overshoot the share by more
+		// than stopping here falls short. Scaled by n to stay integral.
+		share := total * (len(chunks) + 1)
+		here := sent * n
+		next := (sent + len(paths[i+1]) + 1) * n
+		if here >= share || next-share > share-here {
+			chunks = append(chunks, paths[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(paths) {
+		chunks = append(chunks, paths[start:])
+	}
+	return chunks
+}
+
+// splitChunks runs strategy and checks that its chunks tile paths in order,
+// dropping empty ones.
+func splitChunks(strategy ChunkStrategy, paths []string, n int) ([][]string, error) {
+	chunks := strategy(paths, n)
+	if len(chunks) > n {
+		return nil, fmt.Errorf("ignore: chunk strategy returned %d chunks, want at most %d", len(chunks), n)
+	}
+
+	tiled := chunks[:0]
+	next := 0
+	for _, c := range chunks {
+		if len(c) == 0 {
+			continue
+		}
+		if next+len(c) > len(paths) || &c[0] != &paths[next] || &c[len(c)-1] != &paths[next+len(c)-1] {
+			return nil, fmt.Errorf("ignore: chunk strategy returned a chunk that is not the next sub-slice of paths (at path %d)", next)
+		}
+		next += len(c)
+		tiled = append(tiled, c)
+	}
+	if next != len(paths) {
+		return nil, fmt.Errorf("ignore: chunk strategy covered %d of %d paths", next, len(paths))
+	}
+	return tiled, nil
+}
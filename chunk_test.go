@@ -0,0 +1,92 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ChunkStrategy
+// ---------------------------------------------------------------------------
+
+func chunkLens(chunks [][]string) []int {
+	lens := make([]int, len(chunks))
+	for i, c := range chunks {
+		lens[i] = len(c)
+	}
+	return lens
+}
+
+func TestChunkStrategyUniform(t *testing.T) {
+	paths := benchPaths(10)
+	assert.Equal(t, []int{4, 4, 2}, chunkLens(ChunkStrategyUniform(paths, 3)))
+	assert.Equal(t, []int{10}, chunkLens(ChunkStrategyUniform(paths, 1)))
+	assert.Equal(t, []int{10}, chunkLens(ChunkStrategyUniform(paths, 0)), "n below 1 means one chunk")
+	assert.Equal(t, []int{10}, chunkLens(ChunkStrategyDynamic(paths, -1)))
+}
+
+func TestChunkStrategyDynamic(t *testing.T) {
+	long := strings.Repeat("deep/", 20) + "file.go" // 107 bytes
+	paths := []string{long, long, "a", "b", "c", "d", "e", "f", "g", "h"}
+
+	chunks := ChunkStrategyDynamic(paths, 2)
+	assert.Equal(t, []int{1, 9}, chunkLens(chunks), "short paths share the larger chunk")
+	_, err := splitChunks(ChunkStrategyDynamic, paths, 2)
+	require.NoError(t, err)
+
+	for workers := 1; workers <= len(paths); workers++ {
+		_, err := splitChunks(ChunkStrategyDynamic, paths, workers)
+		require.NoError(t, err, "workers=%d", workers)
+	}
+}
+
+func TestSplitChunksRejectsInvalidStrategies(t *testing.T) {
+	paths := benchPaths(6)
+	for name, strategy := range map[string]ChunkStrategy{
+		"too many":  func(p []string, _ int) [][]string { return [][]string{p[:2], p[2:4], p[4:]} },
+		"gap":       func(p []string, _ int) [][]string { return [][]string{p[:2], p[3:]} },
+		"reordered": func(p []string, _ int) [][]string { return [][]string{p[3:], p[:3]} },
+		"copied":    func(p []string, _ int) [][]string { return [][]string{append([]string(nil), p...)} },
+	} {
+		_, err := splitChunks(strategy, paths, 2)
+		assert.Error(t, err, name)
+	}
+
+	chunks, err := splitChunks(func(p []string, _ int) [][]string { return [][]string{nil, p[:3], {}, p[3:]} }, paths, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 3}, chunkLens(chunks), "empty chunks are dropped")
+}
+
+func TestWithChunkStrategyKeepsResult(t *testing.T) {
+	paths := make([]string, 0, 600)
+	for i := range 600 {
+		if i%3 == 0 {
+			paths = append(paths, fmt.Sprintf("%s%d.log", strings.Repeat("nested/", i%17), i))
+		} else {
+			paths = append(paths, fmt.Sprintf("f%d.go", i))
+		}
+	}
+
+	base, err := NewMatcher([]string{"*.log", "!nested/nested/*.log"})
+	require.NoError(t, err)
+	defer func() { _ = base.Close() }()
+	want, err := base.Filter(paths)
+	require.NoError(t, err)
+
+	for name, strategy := range map[string]ChunkStrategy{
+		"uniform": ChunkStrategyUniform,
+		"dynamic": ChunkStrategyDynamic,
+		"single":  func(p []string, _ int) [][]string { return [][]string{p} },
+	} {
+		m, err := NewMatcherWithOptions([]string{"*.log", "!nested/nested/*.log"}, WithChunkStrategy(strategy))
+		require.NoError(t, err)
+		got, err := m.FilterParallelN(paths, 4)
+		require.NoError(t, err, name)
+		assertStringSliceEqual(t, got, want)
+		_ = m.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchDetail explains a match result: which pattern decided it and against
+// which path. It is returned by Matcher.MatchDetail.
+type MatchDetail struct {
+	Result       int    // MatchNone, MatchIgnore, or MatchWhitelist
+	PatternIndex int    // index into Patterns() of the deciding pattern; -1 for MatchNone
+	PatternText  string // the deciding pattern as given; empty for MatchNone
+	MatchedPath  string // the path or ancestor directory matched, as rewritten by any options
+}
+
+// MatchDetail reports the result for path together with the pattern that
+// decided it. As in git, the last pattern matching the path itself wins; if
+// none does, the nearest ancestor directory matched by any pattern decides,
+// which is how "build/" ignores "build/out/app.o".
+//
+// The WASM module only reports the result, so the deciding pattern is found
+// by compiling candidate patterns one at a time on the Matcher's instance.
+// This is far slower than MatchResult and is meant for debugging and
+// tooling, not hot paths.
+func (m *Matcher) MatchDetail(path string, isDir bool) (MatchDetail, error) {
+	none := MatchDetail{Result: MatchNone, PatternIndex: -1}
+	if err := m.checkOpen(); err != nil {
+		return none, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return none, err
+	}
+
+	code, err := m.matchCode(path, isDir)
+	if err != nil || code == MatchNone {
+		return none, err
+	}
+
+	prepared, _ := m.opts.preparePath(path)
+	if strings.HasSuffix(prepared, "/") {
+		prepared = prepared[:len(prepared)-1]
+		isDir = true
+	}
+
+	// Walk from the path itself up through its ancestors; at each level the
+	// last pattern matching exactly that level decides.
+	patterns := m.Patterns()
+	for level := prepared; level != ""; level, isDir = parentPath(level), true {
+		for i := len(patterns) - 1; i >= 0; i-- {
+			p := patterns[i]
+			if p == "" || strings.HasPrefix(p, "#") {
+				continue
+			}
+			hit, err := m.matchesLevel(p, level, isDir)
+			if err != nil {
+				return none, err
+			}
+			if hit {
+				return MatchDetail{
+					Result:       code,
+					PatternIndex: i,
+					PatternText:  p,
+					MatchedPath:  level,
+				}, nil
+			}
+		}
+	}
+	// Unreachable unless the patterns and the compiled handle disagree.
+	return MatchDetail{Result: code, PatternIndex: -1}, nil
+}
+
+// Explain returns a one-line, human-readable account of why path is or is
+// not ignored, built from MatchDetail:
+//
+//	path 'src/debug.log' matched by pattern '*.log' (index 0, line 1) → IGNORE
+//	path 'important.log' overridden by negation pattern '!important.log' (index 1, line 2) → WHITELIST
+//	path 'build/out/app.o' matched by pattern 'build/' via parent directory 'build' (index 3, line 4) → IGNORE
+//	path 'src/main.go' matched no patterns → NOT IGNORED
+//
+// The line is the pattern's 1-based position in Patterns(). The format is
+// meant for people and snapshot tests, not for parsing; use MatchDetail for
+// programmatic access.
+func (m *Matcher) Explain(path string, isDir bool) string {
+	d, err := m.MatchDetail(path, isDir)
+	if err != nil {
+		return fmt.Sprintf("path '%s' could not be matched: %v", path, err)
+	}
+	if d.Result == MatchNone || d.PatternIndex < 0 {
+		return fmt.Sprintf("path '%s' matched no patterns → NOT IGNORED", path)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "path '%s' ", path)
+	if d.Result == MatchWhitelist {
+		fmt.Fprintf(&b, "overridden by negation pattern '%s'", d.PatternText)
+	} else {
+		fmt.Fprintf(&b, "matched by pattern '%s'", d.PatternText)
+	}
+	self, _ := m.opts.preparePath(strings.TrimSuffix(path, "/"))
+	if d.MatchedPath != self {
+		fmt.Fprintf(&b, " via parent directory '%s'", d.MatchedPath)
+	}
+	fmt.Fprintf(&b, " (index %d, line %d) → ", d.PatternIndex, d.PatternIndex+1)
+	if d.Result == MatchWhitelist {
+		b.WriteString("WHITELIST")
+	} else {
+		b.WriteString("IGNORE")
+	}
+	return b.String()
+}
+
+// matchesLevel reports whether pattern matches level itself, as opposed to
+// one of its ancestors. is_match also consults ancestors, so pattern is
+// compiled together with an anchored probe of the opposite polarity for
+// level's parent: if pattern misses level, the probe decides at the parent
+// and the result flips.
+func (m *Matcher) matchesLevel(pattern, level string, isDir bool) (bool, error) {
+	negated := strings.HasPrefix(pattern, "!")
+	want := MatchIgnore
+	if negated {
+		want = MatchWhitelist
+	}
+
+	set := []string{pattern}
+	if parent := parentPath(level); parent != "" {
+		probe := "/" + escapeGlob(parent)
+		if !negated {
+			probe = "!" + probe
+		}
+		set = append(set, probe)
+	}
+
+	handle, err := createMatcherOnInstance(m.eng, m.inst, m.opts.compilePatterns(strings.Join(set, "\x00")))
+	if err != nil {
+		return false, err
+	}
+	defer destroyMatcherOnInstance(m.eng, m.inst, handle)
+
+	code, err := isMatchOnInstance(m.eng, m.inst, handle, level, isDir)
+	return code == want, err
+}
+
+// parentPath returns the slash-separated parent of path, or "" at the top.
+func parentPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// escapeGlob escapes every character of s that gitignore would interpret, so
+// that the result matches s literally.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\*?[]{}! #`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// MatchDetail
+// ---------------------------------------------------------------------------
+
+func TestMatchDetail(t *testing.T) {
+	patterns := []string{"# logs", "*.log", "!important.log", "build/", "*.tmp", "debug.log"}
+	m, err := NewMatcher(patterns)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  MatchDetail
+	}{
+		{"src/main.go", false, MatchDetail{Result: MatchNone, PatternIndex: -1}},
+		{"app.log", false, MatchDetail{MatchIgnore, 1, "*.log", "app.log"}},
+		{"debug.log", false, MatchDetail{MatchIgnore, 5, "debug.log", "debug.log"}},
+		{"logs/important.log", false, MatchDetail{MatchWhitelist, 2, "!important.log", "logs/important.log"}},
+		{"build", true, MatchDetail{MatchIgnore, 3, "build/", "build"}},
+		{"build/", false, MatchDetail{MatchIgnore, 3, "build/", "build"}},
+		{"build/out/app.o", false, MatchDetail{MatchIgnore, 3, "build/", "build"}},
+		{"build/out/x.tmp", false, MatchDetail{MatchIgnore, 4, "*.tmp", "build/out/x.tmp"}},
+	}
+	for _, tt := range tests {
+		got, err := m.MatchDetail(tt.path, tt.isDir)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+
+		ignored, err := m.MatchResult(tt.path, tt.isDir)
+		require.NoError(t, err)
+		assert.Equal(t, ignored, got.Result == MatchIgnore, "%s: must agree with MatchResult", tt.path)
+	}
+}
+
+func TestMatchDetailSpecialCharactersInAncestors(t *testing.T) {
+	m, err := NewMatcher([]string{"[ab]*/", "*.o"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	got, err := m.MatchDetail("a [x]/file.o", false)
+	require.NoError(t, err)
+	assert.Equal(t, MatchDetail{MatchIgnore, 1, "*.o", "a [x]/file.o"}, got)
+
+	got, err = m.MatchDetail("a [x]/file.c", false)
+	require.NoError(t, err)
+	assert.Equal(t, MatchDetail{MatchIgnore, 0, "[ab]*/", "a [x]"}, got)
+}
+
+func TestMatchDetailCaseInsensitive(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.LOG"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	got, err := m.MatchDetail("Logs/App.Log", false)
+	require.NoError(t, err)
+	assert.Equal(t, MatchDetail{MatchIgnore, 0, "*.LOG", "logs/app.log"}, got)
+}
+
+// ---------------------------------------------------------------------------
+// Explain
+// ---------------------------------------------------------------------------
+
+func TestExplain(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "!important.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  string
+	}{
+		{"src/debug.log", false, "path 'src/debug.log' matched by pattern '*.log' (index 0, line 1) → IGNORE"},
+		{"important.log", false, "path 'important.log' overridden by negation pattern '!important.log' (index 1, line 2) → WHITELIST"},
+		{"build", true, "path 'build' matched by pattern 'build/' (index 2, line 3) → IGNORE"},
+		{"build/", false, "path 'build/' matched by pattern 'build/' (index 2, line 3) → IGNORE"},
+		{"build/out/app.o", false, "path 'build/out/app.o' matched by pattern 'build/' via parent directory 'build' (index 2, line 3) → IGNORE"},
+		{"src/main.go", false, "path 'src/main.go' matched no patterns → NOT IGNORED"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, m.Explain(tt.path, tt.isDir))
+	}
+}
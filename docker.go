@@ -0,0 +1,74 @@
+package ignore
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"strings"
+)
+
+// NewDockerMatcher compiles .dockerignore patterns into a Matcher. Docker
+// resolves every pattern against the root of the build context, so unlike
+// gitignore a pattern without a "/" does not match at any depth: "*.log"
+// ignores "debug.log" but not "logs/debug.log", which needs "**/*.log". A
+// leading "/" is redundant, a trailing "/" is dropped, and "**" matches any
+// number of directories, as in Docker.
+//
+// Patterns are translated to anchored gitignore patterns before they reach
+// the WASM module, so the returned Matcher behaves like any other.
+func NewDockerMatcher(patterns []string) (*Matcher, error) {
+	translated := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if g, ok := dockerToGitignore(p); ok {
+			translated = append(translated, g)
+		}
+	}
+	return NewMatcher(translated)
+}
+
+// ParseDockerignore splits .dockerignore text into patterns for
+// NewDockerMatcher, following Docker's reader rather than git's: only lines
+// with "#" in the first column are comments, surrounding whitespace is
+// trimmed from every line, and blank lines are dropped.
+func ParseDockerignore(r io.Reader) ([]string, error) {
+	var patterns []string
+	sc := bufio.NewScanner(r)
+	for first := true; sc.Scan(); first = false {
+		line := sc.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF") // Docker tolerates a UTF-8 BOM
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// dockerToGitignore rewrites a single .dockerignore pattern as the anchored
+// gitignore pattern with the same meaning. It reports false for patterns
+// that match nothing, such as "" or ".".
+func dockerToGitignore(p string) (string, bool) {
+	p = strings.TrimSpace(p)
+	neg := strings.HasPrefix(p, "!")
+	if neg {
+		p = strings.TrimSpace(p[1:])
+	}
+
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "", false
+	}
+
+	p = "/" + p
+	if neg {
+		p = "!" + p
+	}
+	return p, true
+}
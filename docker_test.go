@@ -0,0 +1,75 @@
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// NewDockerMatcher
+// ---------------------------------------------------------------------------
+
+func TestNewDockerMatcher(t *testing.T) {
+	m, err := NewDockerMatcher([]string{"*.log", "!keep.log", "/tmp", "build/", "**/*.bak", "docs/**/draft"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{"debug.log", true},
+		{"logs/debug.log", false}, // no "/" still means the context root
+		{"keep.log", false},
+		{"tmp", true},
+		{"tmp/cache", true},
+		{"src/tmp", false},
+		{"build", true}, // trailing "/" is dropped, so files match too
+		{"build/out.bin", true},
+		{"src/build", false},
+		{"a.bak", true},
+		{"a/b/c.bak", true},
+		{"docs/draft", true},
+		{"docs/en/v1/draft", true},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, m.Match(tt.path), tt.path)
+	}
+}
+
+func TestDockerToGitignore(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"*.log", "/*.log", true},
+		{"/build/", "/build", true},
+		{"!keep.log", "!/keep.log", true},
+		{"! spaced", "!/spaced", true},
+		{"./src//gen/../out", "/src/out", true},
+		{"../escape", "/escape", true},
+		{".", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := dockerToGitignore(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// ParseDockerignore
+// ---------------------------------------------------------------------------
+
+func TestParseDockerignore(t *testing.T) {
+	input := "\uFEFF# comment\r\n  *.log  \n\n #not-a-comment\n!keep.log\n"
+
+	got, err := ParseDockerignore(strings.NewReader(input))
+	require.NoError(t, err)
+	assertStringSliceEqual(t, got, []string{"*.log", "#not-a-comment", "!keep.log"})
+}
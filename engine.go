@@ -3,7 +3,11 @@ package ignore
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"math"
+	"runtime"
+	"slices"
 	"sync"
 	"sync/atomic"
 
@@ -19,12 +23,16 @@ var matcherWasm []byte
 // Each instance has its own linear memory and is NOT safe for concurrent use.
 type wasmInstance struct {
 	mod api.Module
+	id  uint64 // from Engine.instanceCounter; also part of the module name
 	// tainted is set when a wazero Call itself returns a Go error (indicating a
 	// WASM trap or runtime fault). This is distinct from a Rust function
 	// returning a negative i32 error code, which is a normal, safe return path.
 	// After a trap, linear memory state is undefined, so the instance must be
 	// closed and discarded rather than returned to the pool.
 	tainted bool
+	// trap is the error that tainted the instance, reported to the
+	// SetTaintedInstanceLogger callback when the instance is discarded.
+	trap *TrapError
 
 	fnAlloc          api.Function
 	fnDealloc        api.Function
@@ -34,64 +42,400 @@ type wasmInstance struct {
 	fnBatchFilter    api.Function
 }
 
-// engine is the package-level singleton holding the compiled WASM module and
-// a pool of bare instances ready for use.
-type engine struct {
+// taint marks inst as unusable after fn returned a Go-level error from
+// wazero, keeping the first such error for the tainted-instance logger.
+func (inst *wasmInstance) taint(fn string, err error) {
+	inst.tainted = true
+	if inst.trap == nil {
+		inst.trap = &TrapError{InstanceID: inst.id, Func: fn, Err: err}
+	}
+}
+
+// TrapError describes the wazero error, typically a WASM trap, that made an
+// instance unusable. It is what the SetTaintedInstanceLogger callback receives.
+type TrapError struct {
+	InstanceID uint64 // unique per Engine, in creation order
+	Func       string // WASM export that failed, e.g. "is_match"
+	Err        error  // error returned by wazero
+}
+
+func (e *TrapError) Error() string {
+	return fmt.Sprintf("ignore: wasm instance %d: %s failed: %v", e.InstanceID, e.Func, e.Err)
+}
+
+func (e *TrapError) Unwrap() error { return e.Err }
+
+// ErrOutOfMemory is matched by errors.Is when the WASM allocator returned a
+// null pointer. Callers can react by calling CompactMemory, or by splitting
+// the batch, and retrying.
+var ErrOutOfMemory = errors.New("ignore: wasm out of memory")
+
+// AllocError reports a WASM allocation that returned null. The Rust
+// allocator does so for requests it can never satisfy (2GiB or more); running
+// out of linear memory on a smaller request traps instead, which surfaces as
+// a call error on a discarded instance.
+type AllocError struct {
+	RequestedBytes uint32
+}
+
+func (e *AllocError) Error() string {
+	return fmt.Sprintf("ignore: alloc of %d bytes returned null (out of memory)", e.RequestedBytes)
+}
+
+// Is reports whether target is ErrOutOfMemory.
+func (e *AllocError) Is(target error) bool { return target == ErrOutOfMemory }
+
+var taintedLogger atomic.Pointer[func(error)]
+
+// SetTaintedInstanceLogger registers fn to be called with a *TrapError
+// whenever an instance is discarded because a WASM call failed at the
+// wazero level, which would otherwise go unnoticed once the caller has
+// handled its error. fn runs synchronously on the goroutine returning the
+// instance and must be safe for concurrent use. A nil fn removes the logger.
+//
+// fn also receives an error wrapping ErrMatcherLeaked, from the finalizer
+// goroutine, for each Matcher garbage collected without Close. Without a
+// logger those warnings go to log.Println.
+//
+//	ignore.SetTaintedInstanceLogger(func(err error) { slog.Error("wasm trap", "err", err) })
+func SetTaintedInstanceLogger(fn func(error)) {
+	if fn == nil {
+		taintedLogger.Store(nil)
+		return
+	}
+	taintedLogger.Store(&fn)
+}
+
+// MemoryBytes returns the current size of the instance's linear memory.
+// WASM memory only grows, so this is also its high-water mark.
+func (inst *wasmInstance) MemoryBytes() uint32 {
+	return inst.mod.Memory().Size()
+}
+
+// ErrEngineClosed is returned when a matcher needs a WASM instance from an
+// Engine that has been shut down.
+var ErrEngineClosed = errors.New("ignore: engine is shut down")
+
+// Engine holds a compiled WASM module and a pool of bare instances ready for
+// use. The package-level constructors share a default Engine built from the
+// embedded matcher.wasm; NewEngineWithWASM builds one from another module,
+// for use with NewMatcherWithEngine. An Engine is safe for concurrent use.
+type Engine struct {
 	runtime  wazero.Runtime
 	compiled wazero.CompiledModule
-	pool     sync.Pool
 	ctx      context.Context
 
+	// mu guards idle, a LIFO stack of instances ready for reuse. Reusing the
+	// most recently returned instance keeps its memory warm; putInstance
+	// closes the oldest ones once the stack exceeds maxPoolSize.
+	mu     sync.Mutex
+	idle   []*wasmInstance
+	closed bool // set by shutdown; returned instances are closed, not pooled
+
+	// isolated marks an Engine owned by a single Matcher (NewIsolatedMatcher),
+	// which shuts it down on Close.
+	isolated bool
+
+	// refs counts the registry entry and the live Matchers of an Engine
+	// registered in a ModuleRegistry, which shuts down once it drops to zero.
+	// Other Engines leave counted unset and refs at zero.
+	refs    atomic.Int64
+	counted bool
+
+	stats engineCounters
+
 	// instanceCounter generates unique module names (wazero requires them).
 	instanceCounter atomic.Uint64
 }
 
+// engineCounters are the running totals reported by Stats.
+type engineCounters struct {
+	created, destroyed, tainted, oversized atomic.Uint64
+	puts, gets, misses                     atomic.Uint64
+}
+
+// EngineStats is a snapshot of instance and pool activity, for exporting
+// through expvar, Prometheus, or similar. Counters are cumulative since the
+// engine was created; InstancesCreated - InstancesDestroyed is the number of
+// instances currently alive, whether idle or in use by a Matcher.
+type EngineStats struct {
+	InstancesCreated   uint64 // WASM instances instantiated
+	InstancesDestroyed uint64 // instances closed, including tainted ones
+	InstancesTainted   uint64 // instances discarded after a WASM trap
+	InstancesOversized uint64 // instances discarded for exceeding SetMaxInstanceMemory
+	PoolPuts           uint64 // instances returned to the pool
+	PoolGets           uint64 // instances requested from the pool
+	PoolMisses         uint64 // requests the pool could not serve from idle instances
+	PoolIdle           int    // instances idle in the pool right now
+
+	// Linear memory of the idle instances right now: the sum, the largest,
+	// and the mean. Zero when the pool is empty.
+	PoolMemoryBytes    uint64
+	PoolMemoryMaxBytes uint64
+	PoolMemoryAvgBytes uint64
+}
+
+// Stats returns the current EngineStats of the default Engine, compiling the
+// WASM module first if no Matcher has been created yet. If the module cannot
+// be compiled, all fields are zero.
+func Stats() EngineStats {
+	eng, err := getEngine()
+	if err != nil {
+		return EngineStats{}
+	}
+	return eng.Stats()
+}
+
+// Stats returns the current EngineStats of e.
+func (e *Engine) Stats() EngineStats {
+	s := EngineStats{
+		InstancesCreated:   e.stats.created.Load(),
+		InstancesDestroyed: e.stats.destroyed.Load(),
+		InstancesTainted:   e.stats.tainted.Load(),
+		InstancesOversized: e.stats.oversized.Load(),
+		PoolPuts:           e.stats.puts.Load(),
+		PoolGets:           e.stats.gets.Load(),
+		PoolMisses:         e.stats.misses.Load(),
+	}
+
+	e.mu.Lock()
+	s.PoolIdle = len(e.idle)
+	for _, inst := range e.idle {
+		size := uint64(inst.MemoryBytes())
+		s.PoolMemoryBytes += size
+		s.PoolMemoryMaxBytes = max(s.PoolMemoryMaxBytes, size)
+	}
+	e.mu.Unlock()
+
+	if s.PoolIdle > 0 {
+		s.PoolMemoryAvgBytes = s.PoolMemoryBytes / uint64(s.PoolIdle)
+	}
+	return s
+}
+
 var (
-	globalEngine *engine
-	engineOnce   sync.Once
-	engineErr    error
+	// engineMu guards replacing the singleton: getEngine holds it for
+	// reading, Shutdown for writing.
+	engineMu      sync.RWMutex
+	defaultEngine = newEngineLoader()
 )
 
-// getEngine returns the singleton engine, compiling the WASM module on first call.
-func getEngine() (*engine, error) {
-	engineOnce.Do(func() {
-		globalEngine, engineErr = newEngine()
-	})
-	return globalEngine, engineErr
+// engineLoader builds the default Engine on first use. Shutdown discards it
+// and installs a fresh one, which is why the sync.OnceValues is wrapped
+// rather than held in a package variable directly.
+type engineLoader struct {
+	load    func() (*Engine, error)
+	started atomic.Bool // set before the first load, so Shutdown can skip an unused loader
+}
+
+func newEngineLoader() *engineLoader {
+	return &engineLoader{load: sync.OnceValues(func() (*Engine, error) {
+		return newEngine(matcherWasm, defaultRuntimeConfig())
+	})}
+}
+
+var runtimeConfig atomic.Pointer[wazero.RuntimeConfig]
+
+// SetDefaultRuntimeConfig sets the wazero.RuntimeConfig for Engines built
+// without WithRuntimeConfig: the default Engine, the per-Matcher runtimes of
+// NewIsolatedMatcher, and those from NewEngineWithWASM. For example,
+// wazero.NewRuntimeConfigInterpreter() forces the interpreter, to rule out
+// differences between the compiler and interpreter while debugging. An
+// existing default Engine keeps its config until Shutdown; call it before the
+// first Matcher is created. A nil cfg restores wazero.NewRuntimeConfig().
+func SetDefaultRuntimeConfig(cfg wazero.RuntimeConfig) {
+	if cfg == nil {
+		runtimeConfig.Store(nil)
+		return
+	}
+	runtimeConfig.Store(&cfg)
+}
+
+func defaultRuntimeConfig() wazero.RuntimeConfig {
+	if cfg := runtimeConfig.Load(); cfg != nil {
+		return *cfg
+	}
+	return wazero.NewRuntimeConfig()
+}
+
+// getEngine returns the singleton engine, compiling the WASM module on first
+// call, or on the first call after Shutdown.
+func getEngine() (*Engine, error) {
+	engineMu.RLock()
+	defer engineMu.RUnlock()
+	defaultEngine.started.Store(true)
+	return defaultEngine.load()
+}
+
+// Shutdown shuts down the default Engine (see Engine.Shutdown), releasing
+// all memory held by the package. The next NewMatcher (or any other call that
+// needs the default Engine) compiles the module again from scratch, so
+// Shutdown is also useful to give tests a clean slate. Calling it when no
+// default Engine exists is a no-op.
+func Shutdown(ctx context.Context) error {
+	engineMu.Lock()
+	old := defaultEngine
+	defaultEngine = newEngineLoader()
+	engineMu.Unlock()
+
+	if !old.started.Load() {
+		return nil
+	}
+	eng, err := old.load()
+	if err != nil {
+		return nil // nothing was built
+	}
+	return eng.Shutdown(ctx)
+}
+
+// Shutdown closes every pooled WASM instance, the compiled module, and the
+// wazero runtime of e. Matchers created on e must not be used afterwards;
+// closing them is still safe. Further matchers cannot be created on e.
+// Calling Shutdown again is a no-op.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	idle := e.idle
+	e.idle = nil
+	e.mu.Unlock()
+
+	for _, inst := range idle {
+		e.closeInstance(inst)
+	}
+	return errors.Join(
+		e.compiled.Close(ctx),
+		e.runtime.Close(ctx),
+	)
+}
+
+// NewEngineWithWASM compiles wasmBytes into a new Engine, for running a
+// matcher module other than the embedded one, such as a build of the Rust
+// ignore crate with a fix or feature this package does not ship yet. The
+// module must provide the same exports as matcher.wasm; one instance is
+// created up front to check them, then kept in the pool. Use the Engine with
+// NewMatcherWithEngine and call Shutdown when done with it.
+func NewEngineWithWASM(wasmBytes []byte, opts ...EngineOption) (*Engine, error) {
+	o := engineOptions{runtimeConfig: defaultRuntimeConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newCheckedEngine(wasmBytes, o.runtimeConfig)
+}
+
+// EngineOption configures an Engine created by NewEngineWithWASM.
+type EngineOption func(*engineOptions)
+
+type engineOptions struct {
+	runtimeConfig wazero.RuntimeConfig
+}
+
+// WithRuntimeConfig builds the Engine's wazero runtime from cfg instead of
+// the SetDefaultRuntimeConfig one, e.g. to force the interpreter or to cap
+// linear memory with WithMemoryLimitPages:
+//
+//	eng, err := ignore.NewEngineWithWASM(wasm,
+//		ignore.WithRuntimeConfig(wazero.NewRuntimeConfigInterpreter()))
+//
+// A nil cfg is ignored.
+func WithRuntimeConfig(cfg wazero.RuntimeConfig) EngineOption {
+	return func(o *engineOptions) {
+		if cfg != nil {
+			o.runtimeConfig = cfg
+		}
+	}
+}
+
+// NewInterruptibleEngine returns an Engine for the embedded module whose WASM
+// calls stop as soon as their context is done, so MatchResultContext can cut
+// short a call that is already running. The default Engine only checks the
+// context before calling into WASM because termination checks make every
+// call several times slower; use this Engine, via NewMatcherWithEngine, only
+// for matchers that need the guarantee.
+func NewInterruptibleEngine() (*Engine, error) {
+	return newCheckedEngine(matcherWasm, defaultRuntimeConfig().WithCloseOnContextDone(true))
+}
+
+// newIsolatedEngine compiles the embedded module into a new runtime that
+// belongs to a single Matcher.
+func newIsolatedEngine() (*Engine, error) {
+	e, err := newEngine(matcherWasm, defaultRuntimeConfig())
+	if err != nil {
+		return nil, err
+	}
+	e.isolated = true
+	return e, nil
+}
+
+// acquire takes a reference on a counted Engine for a new Matcher. It fails
+// once the count has dropped to zero, as the Engine is then shut down.
+func (e *Engine) acquire() bool {
+	if !e.counted {
+		return true
+	}
+	for {
+		n := e.refs.Load()
+		if n == 0 {
+			return false
+		}
+		if e.refs.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// release drops a reference taken by acquire or by ModuleRegistry.Register,
+// shutting e down when it was the last one.
+func (e *Engine) release() error {
+	if !e.counted || e.refs.Add(-1) != 0 {
+		return nil
+	}
+	return e.Shutdown(e.ctx)
+}
+
+// newCheckedEngine builds an Engine and instantiates the module once to
+// verify its exports, keeping the instance in the pool.
+func newCheckedEngine(wasm []byte, cfg wazero.RuntimeConfig) (*Engine, error) {
+	e, err := newEngine(wasm, cfg)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := e.newInstance()
+	if err != nil {
+		_ = e.Shutdown(e.ctx)
+		return nil, err
+	}
+	e.putInstance(inst)
+	return e, nil
 }
 
-func newEngine() (*engine, error) {
+func newEngine(wasm []byte, cfg wazero.RuntimeConfig) (*Engine, error) {
 	ctx := context.Background()
 
-	r := wazero.NewRuntime(ctx)
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
 
 	wasi_snapshot_preview1.MustInstantiate(ctx, r)
 
-	compiled, err := r.CompileModule(ctx, matcherWasm)
+	compiled, err := r.CompileModule(ctx, wasm)
 	if err != nil {
 		_ = r.Close(ctx)
 		return nil, fmt.Errorf("ignore: failed to compile wasm module: %w", err)
 	}
 
-	e := &engine{
+	e := &Engine{
 		runtime:  r,
 		compiled: compiled,
 		ctx:      ctx,
 	}
 
-	e.pool.New = func() any {
-		inst, err := e.newInstance()
-		if err != nil {
-			return nil // getInstance will retry directly on nil
-		}
-		return inst
-	}
-
 	return e, nil
 }
 
 // newInstance creates a fresh WASM module instance with its own linear memory.
-func (e *engine) newInstance() (*wasmInstance, error) {
+func (e *Engine) newInstance() (*wasmInstance, error) {
 	id := e.instanceCounter.Add(1)
 	name := fmt.Sprintf("matcher_%d", id)
 
@@ -104,7 +448,7 @@ func (e *engine) newInstance() (*wasmInstance, error) {
 		return nil, fmt.Errorf("ignore: failed to instantiate wasm module: %w", err)
 	}
 
-	inst := &wasmInstance{mod: mod}
+	inst := &wasmInstance{mod: mod, id: id}
 
 	inst.fnAlloc = mod.ExportedFunction("alloc")
 	inst.fnDealloc = mod.ExportedFunction("dealloc")
@@ -120,46 +464,217 @@ func (e *engine) newInstance() (*wasmInstance, error) {
 		return nil, fmt.Errorf("ignore: wasm module is missing required exports")
 	}
 
+	e.stats.created.Add(1)
 	return inst, nil
 }
 
+// WarmPool warms the default Engine's pool; see Engine.WarmPool.
+func WarmPool(n int) error {
+	eng, err := getEngine()
+	if err != nil {
+		return err
+	}
+	return eng.WarmPool(n)
+}
+
+// WarmPool creates n WASM instances concurrently and adds them to the
+// instance pool, moving the cost of instantiation (tens of microseconds
+// each) from the first NewMatcher calls to program startup. It blocks until
+// every instance is ready. If any instantiation fails, the instances that
+// were created are still pooled and the errors are returned joined.
+//
+// The pool keeps at most SetMaxPoolSize instances, so warming more than that
+// only costs time.
+func (e *Engine) WarmPool(n int) error {
+	if n <= 0 {
+		return nil
+	}
+
+	insts := make([]*wasmInstance, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := range n {
+		go func() {
+			defer wg.Done()
+			insts[i], errs[i] = e.newInstance()
+		}()
+	}
+	wg.Wait()
+
+	for _, inst := range insts {
+		if inst != nil {
+			e.putInstance(inst)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var maxPoolSize atomic.Int64
+
+func init() {
+	maxPoolSize.Store(int64(runtime.NumCPU() * 2))
+}
+
+// SetMaxPoolSize caps the number of idle WASM instances kept for reuse. Each
+// instance holds its own linear memory (1MB or more), so after a burst of
+// concurrent matchers the pool would otherwise keep all of them alive.
+// Instances returned beyond the cap are closed immediately. The default is
+// twice runtime.NumCPU(). n <= 0 disables pooling: every Close then releases
+// its instance. Lowering the cap takes effect the next time an instance is
+// returned. Safe to call concurrently.
+func SetMaxPoolSize(n int) {
+	maxPoolSize.Store(int64(max(n, 0)))
+}
+
+var maxInstanceMemory atomic.Uint32
+
+// SetMaxInstanceMemory discards instances whose linear memory has grown past
+// limit bytes when they are returned, instead of pooling them. WASM memory
+// never shrinks, so one very large Filter call would otherwise leave an
+// inflated instance in the pool for good. Zero, the default, means no limit.
+// Safe to call concurrently.
+func SetMaxInstanceMemory(limit uint32) {
+	maxInstanceMemory.Store(limit)
+}
+
+// DefaultCompactThreshold is the linear memory size above which CompactMemory
+// discards idle instances: 64 WASM pages of 64KiB, 4MiB.
+const DefaultCompactThreshold uint32 = 64 * 64 << 10
+
+// CompactMemory discards idle instances of the default Engine whose linear
+// memory exceeds DefaultCompactThreshold; see Engine.CompactMemory.
+func CompactMemory() int {
+	eng, err := getEngine()
+	if err != nil {
+		return 0
+	}
+	return eng.CompactMemory(DefaultCompactThreshold)
+}
+
+// CompactMemory closes every idle instance whose linear memory exceeds
+// threshold bytes and returns how many it closed. WASM memory never shrinks,
+// so a burst of large Filter calls leaves inflated instances in the pool;
+// calling CompactMemory periodically, for example from a time.Ticker loop,
+// reclaims that memory. Fresh instances are created on demand. Instances in
+// use by matchers are not affected.
+func (e *Engine) CompactMemory(threshold uint32) int {
+	e.mu.Lock()
+	var discard []*wasmInstance
+	e.idle = slices.DeleteFunc(e.idle, func(inst *wasmInstance) bool {
+		if inst.MemoryBytes() > threshold {
+			discard = append(discard, inst)
+			return true
+		}
+		return false
+	})
+	e.mu.Unlock()
+
+	for _, inst := range discard {
+		e.closeInstance(inst)
+	}
+	return len(discard)
+}
+
 // getInstance retrieves a WASM instance from the pool, or creates one if empty.
-func (e *engine) getInstance() (*wasmInstance, error) {
-	if v := e.pool.Get(); v != nil {
-		return v.(*wasmInstance), nil
+func (e *Engine) getInstance() (*wasmInstance, error) {
+	e.stats.gets.Add(1)
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil, ErrEngineClosed
+	}
+	if n := len(e.idle); n > 0 {
+		inst := e.idle[n-1]
+		e.idle[n-1] = nil
+		e.idle = e.idle[:n-1]
+		e.mu.Unlock()
+		return inst, nil
 	}
+	e.mu.Unlock()
+	e.stats.misses.Add(1)
 	return e.newInstance()
 }
 
 // putInstance returns an instance to the pool. All matchers on it must have
-// been destroyed first. Linear memory grows but never shrinks; the GC reclaims
-// idle instances automatically via sync.Pool eviction.
+// been destroyed first. Linear memory grows but never shrinks, so the pool
+// holds at most maxPoolSize instances and closes the oldest beyond that.
 // Tainted instances (those that experienced a wazero-level Call error) are
 // closed and discarded instead.
-func (e *engine) putInstance(inst *wasmInstance) {
+func (e *Engine) putInstance(inst *wasmInstance) {
 	if inst.tainted {
-		_ = inst.mod.Close(e.ctx)
+		e.stats.tainted.Add(1)
+		e.closeInstance(inst)
+		if fn := taintedLogger.Load(); fn != nil {
+			trap := inst.trap
+			if trap == nil { // tainted without a recorded call error
+				trap = &TrapError{InstanceID: inst.id, Func: "unknown", Err: errors.New("instance tainted")}
+			}
+			(*fn)(trap)
+		}
+		return
+	}
+	if limit := maxInstanceMemory.Load(); limit > 0 && inst.MemoryBytes() > limit {
+		e.stats.oversized.Add(1)
+		e.closeInstance(inst)
 		return
 	}
-	e.pool.Put(inst)
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		e.closeInstance(inst)
+		return
+	}
+	e.stats.puts.Add(1)
+	e.idle = append(e.idle, inst)
+	var surplus []*wasmInstance
+	if n := len(e.idle) - int(maxPoolSize.Load()); n > 0 {
+		surplus = slices.Clone(e.idle[:n])
+		e.idle = slices.Delete(e.idle, 0, n)
+	}
+	e.mu.Unlock()
+
+	for _, inst := range surplus {
+		e.closeInstance(inst)
+	}
+}
+
+// closeInstance closes inst, releasing its linear memory.
+func (e *Engine) closeInstance(inst *wasmInstance) {
+	_ = inst.mod.Close(e.ctx)
+	e.stats.destroyed.Add(1)
+}
+
+// idleCount returns the number of instances currently in the pool.
+func (e *Engine) idleCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.idle)
 }
 
 // writeString allocates WASM memory, writes s into it, and returns ptr+size.
 // The caller must call freeBytes when done.
-func (e *engine) writeString(inst *wasmInstance, s string) (ptr uint32, size uint32, err error) {
+func (e *Engine) writeString(inst *wasmInstance, s string) (ptr uint32, size uint32, err error) {
+	return e.writeStringContext(e.ctx, inst, s)
+}
+
+// writeStringContext is writeString with the alloc call bound to ctx. If ctx
+// is done during the call, wazero closes the instance and it is tainted.
+func (e *Engine) writeStringContext(ctx context.Context, inst *wasmInstance, s string) (ptr uint32, size uint32, err error) {
 	if len(s) == 0 {
 		return 0, 0, nil
 	}
 
 	size = uint32(len(s))
-	results, err := inst.fnAlloc.Call(e.ctx, uint64(size))
+	results, err := inst.fnAlloc.Call(ctx, uint64(size))
 	if err != nil {
-		inst.tainted = true
+		inst.taint("alloc", err)
 		return 0, 0, fmt.Errorf("ignore: alloc failed: %w", err)
 	}
 	ptr = uint32(results[0])
 	if ptr == 0 {
-		return 0, 0, fmt.Errorf("ignore: alloc returned null (out of memory)")
+		return 0, 0, &AllocError{RequestedBytes: size}
 	}
 
 	if !inst.mod.Memory().Write(ptr, []byte(s)) {
@@ -171,8 +686,54 @@ func (e *engine) writeString(inst *wasmInstance, s string) (ptr uint32, size uin
 	return ptr, size, nil
 }
 
+// writeStrings writes strs into a single WASM allocation, separated by sep,
+// and returns its pointer and size. It produces the same bytes as writeString
+// on strings.Join(strs, string(sep)) but copies each string straight into
+// linear memory, so no joined Go string is built. Returns (0, 0, nil) when
+// the joined form would be empty.
+func (e *Engine) writeStrings(inst *wasmInstance, strs []string, sep byte) (ptr uint32, size uint32, err error) {
+	total := max(len(strs)-1, 0)
+	for _, s := range strs {
+		total += len(s)
+	}
+	if total == 0 {
+		return 0, 0, nil
+	}
+	if uint64(total) > math.MaxUint32 {
+		return 0, 0, fmt.Errorf("ignore: %d bytes do not fit in wasm memory", total)
+	}
+
+	size = uint32(total)
+	results, err := inst.fnAlloc.Call(e.ctx, uint64(size))
+	if err != nil {
+		inst.taint("alloc", err)
+		return 0, 0, fmt.Errorf("ignore: alloc failed: %w", err)
+	}
+	ptr = uint32(results[0])
+	if ptr == 0 {
+		return 0, 0, &AllocError{RequestedBytes: size}
+	}
+
+	// Read returns a view of linear memory, valid until the next WASM call.
+	buf, ok := inst.mod.Memory().Read(ptr, size)
+	if !ok {
+		e.freeBytes(inst, ptr, size)
+		return 0, 0, fmt.Errorf("ignore: memory write out of range (ptr=%d, size=%d, mem=%d)",
+			ptr, size, inst.mod.Memory().Size())
+	}
+	off := 0
+	for i, s := range strs {
+		if i > 0 {
+			buf[off] = sep
+			off++
+		}
+		off += copy(buf[off:], s)
+	}
+	return ptr, size, nil
+}
+
 // readBytes reads size bytes from WASM memory at ptr.
-func (e *engine) readBytes(inst *wasmInstance, ptr, size uint32) ([]byte, error) {
+func (e *Engine) readBytes(inst *wasmInstance, ptr, size uint32) ([]byte, error) {
 	if ptr == 0 || size == 0 {
 		return nil, nil
 	}
@@ -187,13 +748,13 @@ func (e *engine) readBytes(inst *wasmInstance, ptr, size uint32) ([]byte, error)
 }
 
 // freeBytes deallocates a previously allocated block in the WASM instance.
-func (e *engine) freeBytes(inst *wasmInstance, ptr, size uint32) {
+func (e *Engine) freeBytes(inst *wasmInstance, ptr, size uint32) {
 	if ptr == 0 || size == 0 {
 		return
 	}
 	// Errors during dealloc are non-fatal — the memory will be reclaimed
 	// when the instance is closed. Taint so the instance is not reused.
 	if _, err := inst.fnDealloc.Call(e.ctx, uint64(ptr), uint64(size)); err != nil {
-		inst.tainted = true
+		inst.taint("dealloc", err)
 	}
 }
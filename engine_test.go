@@ -1,12 +1,15 @@
 package ignore
 
 import (
+	"context"
 	"encoding/binary"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
 )
 
 // ---------------------------------------------------------------------------
@@ -80,6 +83,42 @@ func TestWriteSizeMatchesStringLength(t *testing.T) {
 	}
 }
 
+// TestWriteStringsMatchesJoin verifies that writeStrings lays out exactly
+// the bytes of strings.Join, including empty entries, and allocates nothing
+// when the joined form is empty.
+func TestWriteStringsMatchesJoin(t *testing.T) {
+	eng, err := getEngine()
+	require.NoError(t, err)
+
+	inst, err := eng.getInstance()
+	require.NoError(t, err)
+	defer eng.putInstance(inst)
+
+	for _, strs := range [][]string{
+		{"src/main.go", "debug.log", "build/"},
+		{"", "a", "", "b", ""},
+		{"only"},
+		{"", ""},
+	} {
+		want := strings.Join(strs, "\x00")
+		ptr, size, err := eng.writeStrings(inst, strs, 0)
+		require.NoError(t, err)
+		require.Equal(t, uint32(len(want)), size, "%q", strs)
+
+		got, err := eng.readBytes(inst, ptr, size)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "%q", strs)
+		eng.freeBytes(inst, ptr, size)
+	}
+
+	for _, strs := range [][]string{nil, {""}} {
+		ptr, size, err := eng.writeStrings(inst, strs, 0)
+		require.NoError(t, err)
+		assert.Zero(t, ptr, "%q", strs)
+		assert.Zero(t, size, "%q", strs)
+	}
+}
+
 // TestReadBytesRoundTrip writes known bytes via writeString and reads them
 // back via readBytes, verifying the two copies are identical.
 func TestReadBytesRoundTrip(t *testing.T) {
@@ -240,3 +279,306 @@ func TestBatchFilterResultInfoSize(t *testing.T) {
 
 	eng.freeBytes(inst, resultPtr, resultLen)
 }
+
+// ---------------------------------------------------------------------------
+// WarmPool
+// ---------------------------------------------------------------------------
+
+func TestWarmPool(t *testing.T) {
+	require.NoError(t, WarmPool(4))
+	require.NoError(t, WarmPool(0), "n <= 0 is a no-op")
+
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"), "pooled instances must be usable")
+}
+
+// ---------------------------------------------------------------------------
+// SetMaxPoolSize
+// ---------------------------------------------------------------------------
+
+// setMaxPoolSize sets the pool cap for the duration of the test.
+func setMaxPoolSize(t *testing.T, n int) {
+	t.Helper()
+	prev := int(maxPoolSize.Load())
+	SetMaxPoolSize(n)
+	t.Cleanup(func() { SetMaxPoolSize(prev) })
+}
+
+func TestSetMaxPoolSize(t *testing.T) {
+	eng, err := getEngine()
+	require.NoError(t, err)
+	setMaxPoolSize(t, 2)
+
+	matchers := make([]*Matcher, 5)
+	for i := range matchers {
+		matchers[i], err = NewMatcher([]string{"*.log"})
+		require.NoError(t, err)
+	}
+	for _, m := range matchers {
+		require.NoError(t, m.Close())
+	}
+	assert.Equal(t, 2, eng.idleCount(), "instances beyond the cap must be closed")
+
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	assert.True(t, m.Match("debug.log"), "pooled instance must be reusable")
+	require.NoError(t, m.Close())
+
+	setMaxPoolSize(t, 0)
+	m, err = NewMatcher(nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+	assert.Zero(t, eng.idleCount(), "a zero cap disables pooling")
+}
+
+// ---------------------------------------------------------------------------
+// Stats
+// ---------------------------------------------------------------------------
+
+func TestStats(t *testing.T) {
+	eng, err := getEngine()
+	require.NoError(t, err)
+	setMaxPoolSize(t, 1)
+
+	before := Stats()
+
+	a, err := NewMatcher(nil)
+	require.NoError(t, err)
+	b, err := NewMatcher(nil)
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+	require.NoError(t, b.Close()) // exceeds the cap of 1 and is closed
+
+	inst, err := eng.getInstance()
+	require.NoError(t, err)
+	inst.tainted = true
+	eng.putInstance(inst)
+
+	after := Stats()
+	assert.Equal(t, uint64(3), after.PoolGets-before.PoolGets)
+	assert.Equal(t, uint64(2), after.PoolPuts-before.PoolPuts, "tainted instances are not put back")
+	assert.Equal(t, uint64(1), after.InstancesTainted-before.InstancesTainted)
+	assert.GreaterOrEqual(t, after.InstancesDestroyed-before.InstancesDestroyed, uint64(2))
+	assert.Equal(t, after.PoolMisses-before.PoolMisses, after.InstancesCreated-before.InstancesCreated,
+		"every miss instantiates")
+	assert.Equal(t, 0, after.PoolIdle, "the one pooled instance was taken and tainted")
+	assert.GreaterOrEqual(t, after.InstancesCreated, after.InstancesDestroyed)
+}
+
+// ---------------------------------------------------------------------------
+// Shutdown
+// ---------------------------------------------------------------------------
+
+func TestShutdown(t *testing.T) {
+	old, err := getEngine()
+	require.NoError(t, err)
+
+	live, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	require.NoError(t, WarmPool(2))
+
+	require.NoError(t, Shutdown(context.Background()))
+	assert.Zero(t, old.idleCount(), "pooled instances are closed")
+	assert.NoError(t, live.Close(), "closing a matcher from before Shutdown is safe")
+	assert.Zero(t, old.idleCount(), "instances returned after Shutdown are not pooled")
+
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"), "the engine is rebuilt on demand")
+
+	eng, err := getEngine()
+	require.NoError(t, err)
+	assert.NotSame(t, old, eng)
+}
+
+func TestShutdownWithoutEngine(t *testing.T) {
+	require.NoError(t, Shutdown(context.Background()))
+	require.NoError(t, Shutdown(context.Background()), "a second Shutdown is a no-op")
+}
+
+// ---------------------------------------------------------------------------
+// NewEngineWithWASM / NewMatcherWithEngine
+// ---------------------------------------------------------------------------
+
+func TestNewEngineWithWASM(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+
+	def, err := getEngine()
+	require.NoError(t, err)
+	require.NotSame(t, def, eng)
+
+	m, err := NewMatcherWithEngine([]string{"*.log"}, eng)
+	require.NoError(t, err)
+	assert.Same(t, eng, m.eng)
+	assert.True(t, m.Match("debug.log"))
+	require.NoError(t, m.Close())
+
+	stats := eng.Stats()
+	assert.Equal(t, uint64(1), stats.InstancesCreated, "the validation instance is reused")
+	assert.Equal(t, 1, stats.PoolIdle)
+
+	require.NoError(t, eng.Shutdown(context.Background()))
+	require.NoError(t, eng.Shutdown(context.Background()), "Shutdown is idempotent")
+	_, err = NewMatcherWithEngine(nil, eng)
+	assert.ErrorIs(t, err, ErrEngineClosed)
+}
+
+func TestNewEngineWithWASMInvalid(t *testing.T) {
+	_, err := NewEngineWithWASM([]byte("not wasm"))
+	assert.ErrorContains(t, err, "compile")
+
+	emptyModule := []byte("\x00asm\x01\x00\x00\x00")
+	_, err = NewEngineWithWASM(emptyModule)
+	assert.ErrorContains(t, err, "missing required exports")
+}
+
+func TestNewMatcherWithNilEngine(t *testing.T) {
+	m, err := NewMatcherWithEngine([]string{"*.log"}, nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	def, err := getEngine()
+	require.NoError(t, err)
+	assert.Same(t, def, m.eng)
+}
+
+func TestNewEngineWithRuntimeConfig(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm, WithRuntimeConfig(wazero.NewRuntimeConfigInterpreter()))
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+
+	m, err := NewMatcherWithEngine([]string{"*.log", "!keep.log"}, eng)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"))
+	assert.False(t, m.Match("keep.log"))
+
+	_, err = NewEngineWithWASM(matcherWasm, WithRuntimeConfig(wazero.NewRuntimeConfig().WithMemoryLimitPages(1)))
+	assert.Error(t, err, "the memory limit must reach the runtime")
+}
+
+func TestSetDefaultRuntimeConfig(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, Shutdown(ctx))
+	SetDefaultRuntimeConfig(wazero.NewRuntimeConfig().WithMemoryLimitPages(1))
+	t.Cleanup(func() {
+		SetDefaultRuntimeConfig(nil)
+		_ = Shutdown(ctx)
+	})
+
+	_, err := NewMatcher([]string{"*.log"})
+	assert.Error(t, err, "the default Engine must use the configured runtime")
+	_, err = NewEngineWithWASM(matcherWasm)
+	assert.Error(t, err)
+
+	eng, err := NewEngineWithWASM(matcherWasm, WithRuntimeConfig(wazero.NewRuntimeConfig()))
+	require.NoError(t, err, "WithRuntimeConfig overrides the default")
+	require.NoError(t, eng.Shutdown(ctx))
+
+	SetDefaultRuntimeConfig(nil)
+	require.NoError(t, Shutdown(ctx))
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"))
+}
+
+// ---------------------------------------------------------------------------
+// Instance memory
+// ---------------------------------------------------------------------------
+
+func TestStatsPoolMemory(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+	require.NoError(t, eng.WarmPool(1))
+
+	s := eng.Stats()
+	require.Equal(t, 2, s.PoolIdle)
+	assert.NotZero(t, s.PoolMemoryBytes)
+	assert.Equal(t, s.PoolMemoryBytes/2, s.PoolMemoryAvgBytes)
+	assert.GreaterOrEqual(t, s.PoolMemoryMaxBytes, s.PoolMemoryAvgBytes)
+}
+
+func TestSetMaxInstanceMemory(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+
+	m, err := NewMatcherWithEngine([]string{"*.log"}, eng)
+	require.NoError(t, err)
+	before := m.inst.MemoryBytes()
+
+	// A large batch forces the instance's linear memory to grow.
+	paths := make([]string, 50_000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("some/fairly/long/directory/name/file-%d.go", i)
+	}
+	_, err = m.Filter(paths)
+	require.NoError(t, err)
+	require.Greater(t, m.inst.MemoryBytes(), before)
+
+	SetMaxInstanceMemory(before)
+	t.Cleanup(func() { SetMaxInstanceMemory(0) })
+
+	require.NoError(t, m.Close())
+	s := eng.Stats()
+	assert.Equal(t, uint64(1), s.InstancesOversized)
+	assert.Zero(t, s.PoolIdle, "the grown instance must not be pooled")
+}
+
+// ---------------------------------------------------------------------------
+// CompactMemory
+// ---------------------------------------------------------------------------
+
+func TestCompactMemory(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+
+	small, err := NewMatcherWithEngine(nil, eng)
+	require.NoError(t, err)
+	big, err := NewMatcherWithEngine([]string{"*.log"}, eng)
+	require.NoError(t, err)
+	threshold := big.inst.MemoryBytes()
+
+	paths := make([]string, 50_000)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("some/fairly/long/directory/name/file-%d.go", i)
+	}
+	_, err = big.Filter(paths)
+	require.NoError(t, err)
+	require.Greater(t, big.inst.MemoryBytes(), threshold)
+
+	require.NoError(t, small.Close())
+	require.NoError(t, big.Close())
+	require.Equal(t, 2, eng.Stats().PoolIdle)
+
+	assert.Equal(t, 1, eng.CompactMemory(threshold), "only the grown instance is discarded")
+	assert.Equal(t, 1, eng.Stats().PoolIdle)
+	assert.Zero(t, eng.CompactMemory(threshold))
+
+	assert.GreaterOrEqual(t, CompactMemory(), 0)
+}
+
+func TestAllocErrorOnNullPointer(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	// The allocator rejects layouts of 2GiB or more with a null pointer.
+	err = (&MatchBuffer{m: m, blk: &bufferBlock{}}).grow(1 << 31)
+	require.ErrorIs(t, err, ErrOutOfMemory)
+	var ae *AllocError
+	require.ErrorAs(t, err, &ae)
+	assert.Equal(t, uint32(1<<31), ae.RequestedBytes)
+	assert.Equal(t, "ignore: alloc of 2147483648 bytes returned null (out of memory)", err.Error())
+
+	// A null allocation does not taint the instance.
+	assert.True(t, m.Match("debug.log"))
+}
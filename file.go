@@ -0,0 +1,56 @@
+package ignore
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// NewMatcherFromFile reads gitignore-style patterns from the file at path and
+// compiles them into a Matcher. Comments, blank lines, and "\r\n" line endings
+// are handled on the Go side before the patterns reach the WASM module.
+func NewMatcherFromFile(path string) (*Matcher, error) {
+	patterns, err := ParseGitignoreFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMatcher(patterns)
+}
+
+// NewMatcherFromFS reads gitignore-style patterns from path within fsys and
+// compiles them into a Matcher. The file is parsed the same way as
+// NewMatcherFromFile, which makes this usable with os.DirFS, embed.FS, and
+// other virtual filesystems:
+//
+//	m, err := ignore.NewMatcherFromFS(os.DirFS("."), ".gitignore")
+func NewMatcherFromFS(fsys fs.FS, path string) (*Matcher, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+
+	patterns, err := ParseGitignore(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	return NewMatcher(patterns)
+}
+
+// NewMatcherFromString compiles gitignore-style rules held in a single
+// string, such as a string literal embedded in the program:
+//
+//	const rules = "*.log\nbuild/\n!important.log\n"
+//	m, err := ignore.NewMatcherFromString(rules)
+//
+// It is equivalent to NewMatcher(strings.Split(rules, "\n")), except that a
+// "\r" before each "\n" is dropped so "\r\n" line endings work too; the
+// patterns go through the same compilation as NewMatcher, which skips blank
+// lines and comments.
+func NewMatcherFromString(rules string) (*Matcher, error) {
+	lines := strings.Split(rules, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return NewMatcher(lines)
+}
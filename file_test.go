@@ -0,0 +1,87 @@
+package ignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromFile
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("# logs\r\n*.log\r\n\r\nbuild/\r\n!important.log"), 0o644))
+
+	m, err := NewMatcherFromFile(path)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"))
+	assert.True(t, m.MatchDir("build"))
+	assert.False(t, m.Match("important.log"), "last line without newline must be kept")
+	assert.False(t, m.Match("src/main.go"))
+}
+
+func TestNewMatcherFromFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist")
+
+	_, err := NewMatcherFromFile(path)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Contains(t, err.Error(), path, "error should name the file")
+}
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromFS
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo/.gitignore": {Data: []byte("*.log\r\nbuild/\n# comment\n!important.log")},
+	}
+
+	m, err := NewMatcherFromFS(fsys, "repo/.gitignore")
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"))
+	assert.True(t, m.MatchDir("build"))
+	assert.False(t, m.Match("important.log"))
+}
+
+func TestNewMatcherFromFSMissing(t *testing.T) {
+	_, err := NewMatcherFromFS(fstest.MapFS{}, ".gitignore")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Contains(t, err.Error(), ".gitignore")
+}
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromString
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromString(t *testing.T) {
+	for name, rules := range map[string]string{
+		"LF":   "# logs\n*.log\n\nbuild/\n!important.log\n",
+		"CRLF": "# logs\r\n*.log\r\n\r\nbuild/\r\n!important.log",
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewMatcherFromString(rules)
+			require.NoError(t, err)
+			defer func() { _ = m.Close() }()
+
+			assert.True(t, m.Match("debug.log"))
+			assert.True(t, m.MatchDir("build"))
+			assert.False(t, m.Match("important.log"))
+			assert.False(t, m.Match("# logs"), "comments are not patterns")
+			assert.False(t, m.Match("src/main.go"))
+		})
+	}
+}
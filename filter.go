@@ -0,0 +1,495 @@
+package ignore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// filterChunkSize is the number of paths sent to WASM per batch_filter call by
+// the filtering methods that check for cancellation between batches.
+const filterChunkSize = 4096
+
+// progressChunkSize is the number of paths FilterWithProgress filters between
+// progress reports.
+const progressChunkSize = 1000
+
+// FilterWithContext is like Filter but splits paths into batches and checks
+// ctx before each one, so a long call over millions of paths can be
+// cancelled. On cancellation it returns the kept paths from the batches that
+// completed, together with an error wrapping ctx.Err().
+func (m *Matcher) FilterWithContext(ctx context.Context, paths []string) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for start := 0; start < len(paths); start += filterChunkSize {
+		if err := ctx.Err(); err != nil {
+			return out, fmt.Errorf("ignore: filter cancelled after %d of %d paths: %w", start, len(paths), err)
+		}
+		end := min(start+filterChunkSize, len(paths))
+		kept, err := m.filter(paths[start:end])
+		if err != nil {
+			return out, err
+		}
+		out = append(out, kept...)
+	}
+	return out, nil
+}
+
+// FilterWithProgress is like Filter but filters paths in chunks of 1000 and
+// calls progress(done, total) after each one, so a CLI can drive a progress
+// bar over a large scan. total is len(paths) and the last call has done ==
+// total. progress runs on the calling goroutine; a nil progress is allowed.
+// On error the kept paths are discarded and no further progress is reported.
+func (m *Matcher) FilterWithProgress(paths []string, progress func(done, total int)) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for start := 0; start < len(paths); start += progressChunkSize {
+		end := min(start+progressChunkSize, len(paths))
+		kept, err := m.filter(paths[start:end])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, kept...)
+		if progress != nil {
+			progress(end, len(paths))
+		}
+	}
+	return out, nil
+}
+
+// FilterParallelWithProgress is like FilterParallel but calls progress(done,
+// total) each time a worker finishes its chunk; below the parallel threshold
+// it behaves like FilterWithProgress. Calls are serialized and done only
+// grows, so progress need not be safe for concurrent use even though it may
+// run on a worker goroutine. On success the last call has done == total.
+func (m *Matcher) FilterParallelWithProgress(paths []string, progress func(done, total int)) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if progress == nil {
+		return m.FilterParallel(paths)
+	}
+	if len(paths) == 0 || len(paths) < int(parallelThreshold.Load()) {
+		return m.FilterWithProgress(paths, progress)
+	}
+
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	report := func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		done += n
+		progress(done, len(paths))
+	}
+
+	kept, err := m.filterParallelN(m.opts.ctx, paths, 0, report)
+	if err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// FilterChan filters a stream of paths, emitting those that are NOT ignored on
+// the returned channel in input order. Paths are batched internally to
+// amortize the FFI cost: a batch is sent to WASM once it reaches
+// filterChunkSize paths or in has no further path immediately available, so
+// a slow producer is never stalled waiting for a full batch.
+//
+// Both returned channels are closed once in is closed and drained. The first
+// error is delivered on the error channel, after which the remaining input is
+// discarded so that producers never block. The caller must keep receiving
+// from the path channel until it is closed, and must not use or close m
+// until then. A caller that may stop receiving early should use
+// FilterChanWithContext and cancel its ctx instead; otherwise the filtering
+// goroutine blocks on its next send, and the producer blocks behind it.
+//
+// The error channel is an addition to a plain <-chan string signature: a
+// batch can fail (a WASM trap, a closed Matcher, a done WithContext ctx),
+// and with only the path channel a failure would look like the end of the
+// stream. The error channel has a buffer of one, so a caller that never
+// receives from it blocks nothing and leaks nothing, but then cannot tell a
+// truncated stream from a complete one.
+func (m *Matcher) FilterChan(in <-chan string) (<-chan string, <-chan error) {
+	return m.filterChan(context.Background(), in, filterChunkSize)
+}
+
+// FilterChanWithBatchSize is like FilterChan but sends at most batchSize
+// paths to WASM per call. Values below 1 are treated as 1.
+func (m *Matcher) FilterChanWithBatchSize(in <-chan string, batchSize int) (<-chan string, <-chan error) {
+	return m.filterChan(context.Background(), in, batchSize)
+}
+
+// FilterChanWithContext is like FilterChan but stops once ctx is done, which
+// lets a consumer abandon the path channel without leaking the filtering
+// goroutine. The same applies when the WithContext ctx is done. Either way
+// the context error is delivered on the error channel and both channels are
+// closed right away; the rest of in is then discarded in the background until
+// in is closed, so producers never block.
+func (m *Matcher) FilterChanWithContext(ctx context.Context, in <-chan string) (<-chan string, <-chan error) {
+	return m.filterChan(ctx, in, filterChunkSize)
+}
+
+// filterChan implements the FilterChan family.
+func (m *Matcher) filterChan(ctx context.Context, in <-chan string, batchSize int) (<-chan string, <-chan error) {
+	batchSize = max(batchSize, 1)
+
+	out := make(chan string, batchSize)
+	errc := make(chan error, 1)
+	drain := func() {
+		for range in {
+		}
+	}
+	if err := m.checkOpen(); err != nil {
+		errc <- err
+		close(errc)
+		close(out)
+		go drain()
+		return out, errc
+	}
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		mctx := m.opts.ctx
+		cancelled := func(err error) {
+			errc <- fmt.Errorf("ignore: filter cancelled: %w", err)
+			go drain()
+		}
+
+		batch := make([]string, 0, batchSize)
+		for {
+			var (
+				p  string
+				ok bool
+			)
+			select {
+			case p, ok = <-in:
+			case <-ctx.Done():
+				cancelled(ctx.Err())
+				return
+			case <-mctx.Done():
+				cancelled(mctx.Err())
+				return
+			}
+			if !ok {
+				return
+			}
+
+			batch = append(batch[:0], p)
+		fill: // take whatever else is ready without waiting
+			for len(batch) < batchSize {
+				select {
+				case p, ok := <-in:
+					if !ok {
+						break fill
+					}
+					batch = append(batch, p)
+				default:
+					break fill
+				}
+			}
+
+			if err := ctx.Err(); err != nil {
+				cancelled(err)
+				return
+			}
+			kept, err := m.Filter(batch)
+			if err != nil {
+				errc <- err
+				drain()
+				return
+			}
+			for _, k := range kept {
+				select {
+				case out <- k:
+				case <-ctx.Done():
+					cancelled(ctx.Err())
+					return
+				case <-mctx.Done():
+					cancelled(mctx.Err())
+					return
+				}
+			}
+		}
+	}()
+	return out, errc
+}
+
+// FilterToWriter filters paths as Filter does and writes each kept path to w
+// followed by "\n". It returns the number of paths written; on a write error
+// it stops there and returns that error. Wrap w in a bufio.Writer when it is
+// unbuffered, such as os.Stdout.
+func (m *Matcher) FilterToWriter(paths []string, w io.Writer) (int, error) {
+	kept, err := m.Filter(paths)
+	if err != nil {
+		return 0, err
+	}
+
+	var line []byte
+	for i, p := range kept {
+		line = append(append(line[:0], p...), '\n')
+		if _, err := w.Write(line); err != nil {
+			return i, err
+		}
+	}
+	return len(kept), nil
+}
+
+// FilterScanToWriter is the streaming form of FilterToWriter: it filters the
+// paths received from in as FilterChan does and writes each kept path to w as
+// soon as its batch is done. It returns once in is closed and drained. After
+// a write error the remaining paths are still consumed, so producers never
+// block, but nothing more is written; the write error takes precedence over a
+// filtering error.
+func (m *Matcher) FilterScanToWriter(in <-chan string, w io.Writer) (int, error) {
+	out, errc := m.FilterChan(in)
+
+	var (
+		n    int
+		werr error
+		line []byte
+	)
+	for p := range out {
+		if werr != nil {
+			continue
+		}
+		line = append(append(line[:0], p...), '\n')
+		if _, werr = w.Write(line); werr == nil {
+			n++
+		}
+	}
+	if werr != nil {
+		return n, werr
+	}
+	return n, <-errc
+}
+
+// FilterSeq returns a sequence of the paths from seq that are NOT ignored, in
+// input order, for use with range-over-func, together with a function that
+// reports the error that ended the sequence early:
+//
+//	kept, errf := m.FilterSeq(paths)
+//	for path := range kept {
+//		...
+//	}
+//	if err := errf(); err != nil {
+//		return err
+//	}
+//
+// Paths are pulled from seq in batches of up to filterChunkSize and filtered
+// with one batch_filter call per batch. A failed batch ends the sequence
+// without yielding anything from it, so a truncated sequence is told apart
+// from a complete one only by errf. errf returns nil after a complete or
+// abandoned iteration, and is reset each time the sequence is ranged over;
+// call it after the loop, not concurrently with it. Breaking out of the loop
+// stops pulling from seq.
+func (m *Matcher) FilterSeq(seq iter.Seq[string]) (iter.Seq[string], func() error) {
+	var err error
+	filtered := func(yield func(string) bool) {
+		err = nil
+		batch := make([]string, 0, filterChunkSize)
+		flush := func() bool {
+			var kept []string
+			kept, err = m.Filter(batch)
+			batch = batch[:0]
+			if err != nil {
+				return false
+			}
+			for _, k := range kept {
+				if !yield(k) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for p := range seq {
+			batch = append(batch, p)
+			if len(batch) == filterChunkSize && !flush() {
+				return
+			}
+		}
+		if len(batch) > 0 {
+			flush()
+		}
+	}
+	return filtered, func() error { return err }
+}
+
+// SplitFilter partitions paths into those that are kept and those that are
+// ignored, both in input order, using a single batch_filter round-trip.
+// Paths ending with "/" are treated as directories, as in Filter. It is the
+// same as PartitionPaths.
+func (m *Matcher) SplitFilter(paths []string) (kept, ignored []string, err error) {
+	return m.PartitionPaths(paths)
+}
+
+// PartitionPaths splits paths into two disjoint slices, kept and ignored,
+// each in input order, with a single batch_filter round-trip. kept is what
+// Filter returns; ignored is recovered with one linear two-pointer pass,
+// since kept is an in-order subsequence of paths, and is the only extra
+// allocation. Either slice is nil when empty.
+//
+// Empty paths are the exception: Filter drops them, but Match never ignores
+// "", so they are placed in kept, which is then rebuilt in the same pass.
+func (m *Matcher) PartitionPaths(paths []string) (kept, ignored []string, err error) {
+	filtered, err := m.Filter(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	empty := 0
+	for _, p := range paths {
+		if p == "" {
+			empty++
+		}
+	}
+
+	kept = filtered
+	if empty > 0 {
+		kept = make([]string, 0, len(filtered)+empty)
+	}
+	if n := len(paths) - len(filtered) - empty; n > 0 {
+		ignored = make([]string, 0, n)
+	}
+	if empty == 0 && ignored == nil {
+		return kept, nil, nil
+	}
+	j := 0
+	for _, p := range paths {
+		switch {
+		case j < len(filtered) && filtered[j] == p:
+			j++
+		case p == "":
+		default:
+			ignored = append(ignored, p)
+			continue
+		}
+		if empty > 0 {
+			kept = append(kept, p)
+		}
+	}
+	return kept, ignored, nil
+}
+
+// ListIgnored is the complement of Filter: it returns only the ignored paths,
+// in input order and exactly as given, including any trailing "/". Every
+// non-empty path appears in exactly one of Filter(paths) and
+// ListIgnored(paths), so together they make up paths less its empty entries,
+// which Filter drops and which are never ignored. It costs one batch_filter
+// round-trip, as PartitionPaths does.
+func (m *Matcher) ListIgnored(paths []string) ([]string, error) {
+	_, ignored, err := m.PartitionPaths(paths)
+	return ignored, err
+}
+
+// FilterFunc filters items of any type by the path pathOf extracts from each,
+// returning the items that are NOT ignored in their original order, or nil
+// if there are none. An item is matched as a directory when isDirOf reports
+// true or its path ends with "/"; isDirOf may be nil. The paths are sent in a
+// single batch_filter round-trip, as with Filter:
+//
+//	kept, err := ignore.FilterFunc(m, infos,
+//	    func(fi fs.FileInfo) string { return fi.Name() },
+//	    fs.FileInfo.IsDir)
+func FilterFunc[T any](m *Matcher, items []T, pathOf func(T) string, isDirOf func(T) bool) ([]T, error) {
+	paths := make([]string, len(items))
+	for i, item := range items {
+		paths[i] = pathOf(item)
+		if isDirOf != nil && isDirOf(item) && !strings.HasSuffix(paths[i], "/") {
+			paths[i] += "/"
+		}
+	}
+
+	mask, err := m.keptMask(paths)
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	for i, item := range items {
+		if mask[i] {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// IgnoredMask reports, for each entry of paths, whether it is ignored. The
+// result has the same length as paths and comes from a single batch_filter
+// round-trip, which suits callers annotating an existing tree rather than
+// partitioning it. An empty path is never ignored, as with Match.
+func (m *Matcher) IgnoredMask(paths []string) ([]bool, error) {
+	mask, err := m.keptMask(paths)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mask {
+		// batch_filter drops empty entries, so they are absent from kept.
+		mask[i] = !mask[i] && paths[i] != ""
+	}
+	return mask, nil
+}
+
+// CountIgnored returns how many of paths are ignored. It makes the same single
+// batch_filter round-trip as Filter but uses the kept count reported by the
+// WASM module, so the kept paths are never copied back into Go memory. An
+// empty path is never ignored, as with Match.
+func (m *Matcher) CountIgnored(paths []string) (int, error) {
+	if err := m.checkOpen(); err != nil {
+		return 0, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// batch_filter drops empty entries, which would count them as ignored,
+	// so they are left out like the paths preparePath skips.
+	sent := paths
+	if m.opts.rewritesPaths() || slices.Contains(paths, "") {
+		sent = make([]string, 0, len(paths))
+		for _, p := range paths {
+			if prepared, ok := m.opts.preparePath(p); ok && prepared != "" {
+				sent = append(sent, prepared)
+			}
+		}
+	}
+	if len(sent) == 0 {
+		return 0, nil
+	}
+
+	kept, _, err := batchFilterCall(m.eng, m.inst, m.handle, sent, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	return len(sent) - kept, nil
+}
+
+// keptMask runs Filter over paths and reports, per input, whether it was kept.
+func (m *Matcher) keptMask(paths []string) ([]bool, error) {
+	kept, err := m.Filter(paths)
+	if err != nil {
+		return nil, err
+	}
+	return keptMask(paths, kept), nil
+}
@@ -0,0 +1,627 @@
+package ignore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// SplitFilter
+// ---------------------------------------------------------------------------
+
+func TestSplitFilter(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/", "!keep.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	kept, ignored, err := m.SplitFilter([]string{
+		"main.go", "debug.log", "build/", "keep.log", "build/out.bin", "README.md",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "keep.log", "README.md"}, kept)
+	assert.Equal(t, []string{"debug.log", "build/", "build/out.bin"}, ignored)
+}
+
+func TestSplitFilterDuplicatesAndOptions(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	kept, ignored, err := m.SplitFilter([]string{"A.LOG", "main.go", "A.LOG", "main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "main.go"}, kept)
+	assert.Equal(t, []string{"A.LOG", "A.LOG"}, ignored)
+}
+
+func TestSplitFilterEmpty(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	kept, ignored, err := m.SplitFilter(nil)
+	require.NoError(t, err)
+	assert.Nil(t, kept)
+	assert.Nil(t, ignored)
+}
+
+func TestPartitionPaths(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := []string{"a.log", "main.go", "build/", "a.log", "b.log"}
+	kept, ignored, err := m.PartitionPaths(paths)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, kept)
+	assert.Equal(t, []string{"a.log", "build/", "a.log", "b.log"}, ignored)
+	assert.Equal(t, len(ignored), cap(ignored), "ignored is allocated exactly once")
+
+	kept, ignored, err = m.PartitionPaths([]string{"x.go", "y.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x.go", "y.go"}, kept)
+	assert.Nil(t, ignored)
+}
+
+func TestPartitionPathsEmptyPath(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	kept, ignored, err := m.PartitionPaths([]string{"", "a.go", "b.log", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "a.go", ""}, kept, "empty paths are never ignored")
+	assert.Equal(t, []string{"b.log"}, ignored)
+	assert.Equal(t, len(ignored), cap(ignored))
+
+	kept, ignored, err = m.PartitionPaths([]string{""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, kept)
+	assert.Nil(t, ignored)
+}
+
+func TestListIgnoredComplementsFilter(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log", "build/", "!keep.log"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := []string{"Debug.LOG", "main.go", "build/", "keep.log", "build", "x/y.log"}
+	ignored, err := m.ListIgnored(paths)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Debug.LOG", "build/", "x/y.log"}, ignored, "original strings are returned")
+
+	kept, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, paths, append(kept, ignored...))
+}
+
+func TestListIgnoredEmptyPath(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	ignored, err := m.ListIgnored([]string{"", "a.go", "b.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.log"}, ignored, "an empty path is not ignored")
+
+	ignored, err = m.ListIgnored([]string{""})
+	require.NoError(t, err)
+	assert.Nil(t, ignored)
+}
+
+// ---------------------------------------------------------------------------
+// FilterFunc
+// ---------------------------------------------------------------------------
+
+func TestFilterFunc(t *testing.T) {
+	type file struct {
+		path string
+		dir  bool
+	}
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	items := []file{
+		{"main.go", false},
+		{"debug.log", false},
+		{"build", true},  // directory via isDirOf
+		{"build", false}, // a file named build is kept
+		{"out/build/", false},
+		{"main.go", false},
+	}
+	got, err := FilterFunc(m, items,
+		func(f file) string { return f.path },
+		func(f file) bool { return f.dir })
+	require.NoError(t, err)
+	assert.Equal(t, []file{{"main.go", false}, {"build", false}, {"main.go", false}}, got)
+
+	got, err = FilterFunc(m, items[1:3], func(f file) string { return f.path }, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []file{{"build", true}}, got, "without isDirOf only a trailing / marks a directory")
+
+	got, err = FilterFunc(m, nil, func(f file) string { return f.path }, nil)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+// ---------------------------------------------------------------------------
+// IgnoredMask
+// ---------------------------------------------------------------------------
+
+func TestIgnoredMask(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/", "!keep.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	mask, err := m.IgnoredMask([]string{"main.go", "debug.log", "build/", "keep.log", "debug.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, true, false, true}, mask)
+}
+
+func TestIgnoredMaskAllKept(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	mask, err := m.IgnoredMask([]string{"a.go", "b.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false}, mask)
+
+	mask, err = m.IgnoredMask(nil)
+	require.NoError(t, err)
+	assert.Empty(t, mask)
+}
+
+func TestIgnoredMaskEmptyPath(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	mask, err := m.IgnoredMask([]string{"", "a.go", "b.log", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false, true, false}, mask)
+	assert.False(t, m.Match(""))
+}
+
+// ---------------------------------------------------------------------------
+// CountIgnored
+// ---------------------------------------------------------------------------
+
+func TestCountIgnored(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/", "!keep.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := []string{"main.go", "debug.log", "build/", "keep.log", "build/out.bin"}
+	n, err := m.CountIgnored(paths)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	kept, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, len(paths)-len(kept), n, "must agree with Filter")
+
+	n, err = m.CountIgnored([]string{"main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = m.CountIgnored(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestCountIgnoredEmptyPath(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	n, err := m.CountIgnored([]string{"", "a.go", "b.log", ""})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = m.CountIgnored([]string{""})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestCountIgnoredWithBaseDirStrict(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithBaseDir("/repo"), WithBaseDirStrict())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	n, err := m.CountIgnored([]string{"/repo/a.log", "/other/b.log", "/repo/c.go"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "paths outside the base dir are never ignored")
+}
+
+// ---------------------------------------------------------------------------
+// FilterWithContext
+// ---------------------------------------------------------------------------
+
+func TestFilterWithContextMatchesFilter(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := make([]string, 0, 3*filterChunkSize)
+	for i := range 3 * filterChunkSize {
+		if i%3 == 0 {
+			paths = append(paths, fmt.Sprintf("logs/%d.log", i))
+		} else {
+			paths = append(paths, fmt.Sprintf("src/%d.go", i))
+		}
+	}
+
+	want, err := m.Filter(paths)
+	require.NoError(t, err)
+	got, err := m.FilterWithContext(context.Background(), paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestFilterWithContextCancelled(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := m.FilterWithContext(ctx, []string{"a.go", "b.log"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, got, "no batch ran before cancellation")
+}
+
+// ---------------------------------------------------------------------------
+// FilterWithProgress
+// ---------------------------------------------------------------------------
+
+// progressPaths returns n paths of which every third is a .log file.
+func progressPaths(n int) []string {
+	paths := make([]string, n)
+	for i := range paths {
+		if i%3 == 0 {
+			paths[i] = fmt.Sprintf("logs/%d.log", i)
+		} else {
+			paths[i] = fmt.Sprintf("src/%d.go", i)
+		}
+	}
+	return paths
+}
+
+func TestFilterWithProgress(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := progressPaths(2500)
+	var calls [][2]int
+	got, err := m.FilterWithProgress(paths, func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	})
+	require.NoError(t, err)
+
+	want, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, [][2]int{{1000, 2500}, {2000, 2500}, {2500, 2500}}, calls)
+
+	got, err = m.FilterWithProgress(nil, func(int, int) { t.Error("no progress for empty input") })
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestFilterParallelWithProgress(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithBaseDir("/repo"), WithBaseDirStrict())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := progressPaths(5000)
+	for i := 0; i < len(paths); i += 100 {
+		paths[i] = "/elsewhere/" + paths[i] // never sent to a worker
+	}
+
+	var calls [][2]int
+	got, err := m.FilterParallelWithProgress(paths, func(done, total int) {
+		calls = append(calls, [2]int{done, total}) // serialized by the Matcher
+	})
+	require.NoError(t, err)
+
+	want, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	require.NotEmpty(t, calls)
+	for i, c := range calls {
+		assert.Equal(t, len(paths), c[1])
+		if i > 0 {
+			assert.Greater(t, c[0], calls[i-1][0], "done must grow")
+		}
+	}
+	assert.Equal(t, len(paths), calls[len(calls)-1][0])
+}
+
+// ---------------------------------------------------------------------------
+// FilterChan
+// ---------------------------------------------------------------------------
+
+func TestFilterChan(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for i := range 1000 {
+			if i%2 == 0 {
+				in <- fmt.Sprintf("logs/%d.log", i)
+			} else {
+				in <- fmt.Sprintf("src/%d.go", i)
+			}
+		}
+		in <- "build/"
+	}()
+
+	out, errc := m.FilterChanWithBatchSize(in, 64)
+	var got []string
+	for p := range out {
+		got = append(got, p)
+	}
+	require.NoError(t, <-errc)
+
+	require.Len(t, got, 500)
+	assert.Equal(t, "src/1.go", got[0])
+	assert.Equal(t, "src/999.go", got[499])
+}
+
+func TestFilterChanErrorDrainsInput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	cancel()
+
+	in := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(in)
+		for range 100 {
+			in <- "a.go" // must not block after the error
+		}
+	}()
+
+	out, errc := m.FilterChan(in)
+	for range out {
+	}
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	<-done
+}
+
+func TestFilterChanAbandonedConsumerDoesNotLeak(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(in)
+		for i := range 10 * filterChunkSize {
+			in <- fmt.Sprintf("f%d.go", i)
+		}
+	}()
+
+	out, errc := m.FilterChanWithContext(ctx, in)
+	<-out // read one path, then walk away
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("producer still blocked after cancel")
+	}
+	assert.ErrorIs(t, <-errc, context.Canceled)
+	assert.Eventually(t, func() bool { return runtime.NumGoroutine() <= before },
+		10*time.Second, 10*time.Millisecond, "the filtering goroutine must exit")
+}
+
+func TestFilterChanStopsWhenMatcherContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	in := make(chan string) // never closed
+	out, errc := m.FilterChan(in)
+	in <- "a.go"
+	<-out
+	cancel()
+
+	select {
+	case err := <-errc:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(10 * time.Second):
+		t.Fatal("FilterChan ignored the WithContext ctx")
+	}
+	_, open := <-out
+	assert.False(t, open)
+	close(in)
+}
+
+// ---------------------------------------------------------------------------
+// FilterToWriter / FilterScanToWriter
+// ---------------------------------------------------------------------------
+
+// failingWriter accepts ok writes and then fails every later one.
+type failingWriter struct {
+	strings.Builder
+	ok int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.ok == 0 {
+		return 0, errWriteFailed
+	}
+	w.ok--
+	return w.Builder.Write(p)
+}
+
+func TestFilterToWriter(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	var sb strings.Builder
+	n, err := m.FilterToWriter([]string{"a.go", "b.log", "build/", "docs/", "c.md"}, &sb)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "a.go\ndocs/\nc.md\n", sb.String())
+
+	w := &failingWriter{ok: 1}
+	n, err = m.FilterToWriter([]string{"a.go", "b.go", "c.go"}, w)
+	assert.ErrorIs(t, err, errWriteFailed)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "a.go\n", w.String())
+}
+
+func TestFilterScanToWriter(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, p := range []string{"a.go", "b.log", "c.go", "d.log", "e.md"} {
+			in <- p
+		}
+	}()
+
+	var sb strings.Builder
+	n, err := m.FilterScanToWriter(in, &sb)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "a.go\nc.go\ne.md\n", sb.String())
+}
+
+func TestFilterScanToWriterWriteErrorDrainsInput(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for i := range 3 * filterChunkSize {
+			in <- fmt.Sprintf("src/%d.go", i) // blocks forever if nobody drains
+		}
+	}()
+
+	w := &failingWriter{ok: 2}
+	n, err := m.FilterScanToWriter(in, w)
+	assert.ErrorIs(t, err, errWriteFailed)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "src/0.go\nsrc/1.go\n", w.String())
+}
+
+// ---------------------------------------------------------------------------
+// FilterSeq
+// ---------------------------------------------------------------------------
+
+func TestFilterSeq(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := make([]string, 0, filterChunkSize+10)
+	for i := range filterChunkSize + 10 {
+		paths = append(paths, fmt.Sprintf("f%d.log", i))
+	}
+	paths = append(paths, "first.go", "second.go")
+
+	kept, errf := m.FilterSeq(slices.Values(paths))
+	var got []string
+	for p := range kept {
+		got = append(got, p)
+	}
+	require.NoError(t, errf())
+	assert.Equal(t, []string{"first.go", "second.go"}, got)
+}
+
+func TestFilterSeqBreakStopsPulling(t *testing.T) {
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	pulled := 0
+	src := func(yield func(string) bool) {
+		for i := 0; ; i++ {
+			pulled++
+			if !yield(fmt.Sprintf("f%d.go", i)) {
+				return
+			}
+		}
+	}
+
+	kept, errf := m.FilterSeq(src)
+	for p := range kept {
+		assert.Equal(t, "f0.go", p)
+		break
+	}
+	require.NoError(t, errf())
+	assert.Equal(t, filterChunkSize, pulled, "only the first batch should be pulled")
+}
+
+func TestFilterSeqError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	cancel()
+
+	kept, errf := m.FilterSeq(slices.Values([]string{"a.go"}))
+	for p := range kept {
+		t.Fatalf("a failed batch yielded %q", p)
+	}
+	assert.ErrorIs(t, errf(), context.Canceled)
+}
+
+func TestFilterSeqErrorNeverReachesKeyOnlyLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := make([]string, 0, 2*filterChunkSize)
+	for i := range 2 * filterChunkSize {
+		paths = append(paths, fmt.Sprintf("f%d.go", i))
+	}
+
+	kept, errf := m.FilterSeq(slices.Values(paths))
+	var got []string
+	for p := range kept {
+		got = append(got, p)
+		cancel() // the second batch fails
+	}
+	assert.Equal(t, paths[:filterChunkSize], got, "only the first batch is yielded")
+	assert.NotContains(t, got, "")
+	assert.ErrorIs(t, errf(), context.Canceled)
+}
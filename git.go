@@ -0,0 +1,201 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadGlobalGitignore returns the patterns of the user's global gitignore, so
+// that custom tooling can honour rules like ".DS_Store" or "*.swp" the way
+// git does. Merge them with repository patterns by appending the repository
+// patterns last, so that they take precedence:
+//
+//	global, err := ignore.LoadGlobalGitignore()
+//	m, err := ignore.NewMatcher(append(global, repoPatterns...))
+//
+// The file is located like git locates it: core.excludesFile from
+// ~/.gitconfig or $XDG_CONFIG_HOME/git/config, falling back to
+// $XDG_CONFIG_HOME/git/ignore ($XDG_CONFIG_HOME defaults to ~/.config) and
+// finally ~/.gitignore_global. The home directory comes from
+// os.UserHomeDir, so %USERPROFILE% is used on Windows. A missing file is not
+// an error and yields no patterns.
+func LoadGlobalGitignore() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("ignore: locating global gitignore: %w", err)
+	}
+	path, err := globalGitignorePath(home, os.Getenv("XDG_CONFIG_HOME"))
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	patterns, err := ParseGitignoreFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return patterns, err
+}
+
+// LoadGitInfoExclude returns the patterns in the repository-local exclude
+// file, .git/info/exclude, of the repository rooted at repoRoot. These have
+// the same semantics as .gitignore but are never committed. If repoRoot is
+// not a git repository or the file does not exist, it returns (nil, nil).
+//
+// A .git file pointing elsewhere ("gitdir: ..."), as used by submodules and
+// linked worktrees, is followed; worktrees share the main repository's
+// exclude file.
+func LoadGitInfoExclude(repoRoot string) ([]string, error) {
+	gitDir, err := resolveGitDir(repoRoot)
+	if err != nil || gitDir == "" {
+		return nil, err
+	}
+
+	patterns, err := ParseGitignoreFile(filepath.Join(gitDir, "info", "exclude"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return patterns, err
+}
+
+// resolveGitDir returns the git directory holding info/exclude for the
+// repository at repoRoot, or "" if repoRoot has no .git entry.
+func resolveGitDir(repoRoot string) (string, error) {
+	dotGit := filepath.Join(repoRoot, ".git")
+	fi, err := os.Stat(dotGit)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ignore: reading %s: %w", dotGit, err)
+	}
+	if fi.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("ignore: reading %s: %w", dotGit, err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", nil
+	}
+	gitDir = resolveRelative(repoRoot, strings.TrimSpace(gitDir))
+
+	// Linked worktrees point at .git/worktrees/<name>, whose "commondir" file
+	// leads back to the shared git directory.
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		gitDir = resolveRelative(gitDir, strings.TrimSpace(string(common)))
+	}
+	return gitDir, nil
+}
+
+// resolveRelative joins path onto base unless path is already absolute.
+func resolveRelative(base, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
+
+// globalGitignorePath returns the global gitignore file to read, or "" if
+// core.excludesFile is unset and none of the default locations exist.
+func globalGitignorePath(home, xdgConfigHome string) (string, error) {
+	if xdgConfigHome == "" {
+		xdgConfigHome = filepath.Join(home, ".config")
+	}
+
+	var excludesFile string
+	for _, cfg := range []string{
+		filepath.Join(xdgConfigHome, "git", "config"),
+		filepath.Join(home, ".gitconfig"), // read last: takes precedence
+	} {
+		v, err := gitConfigValue(cfg, "core", "excludesfile")
+		if err != nil {
+			return "", err
+		}
+		if v != "" {
+			excludesFile = v
+		}
+	}
+	if excludesFile != "" {
+		return expandHome(excludesFile, home), nil
+	}
+
+	for _, candidate := range []string{
+		filepath.Join(xdgConfigHome, "git", "ignore"),
+		filepath.Join(home, ".gitignore_global"),
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// gitConfigValue returns the last value of section.key in the git config file
+// at path, or "" if the file or key does not exist. Section and key names are
+// compared case-insensitively; include directives are not followed.
+func gitConfigValue(path, section, key string) (string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var value, current string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			name, _, _ := strings.Cut(strings.Trim(line, "[]"), " ")
+			current = strings.ToLower(name)
+			continue
+		}
+		if current != section {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			value = unquoteGitConfig(strings.TrimSpace(v))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// unquoteGitConfig strips a trailing comment and surrounding double quotes
+// from a git config value.
+func unquoteGitConfig(v string) string {
+	if strings.HasPrefix(v, `"`) {
+		if end := strings.Index(v[1:], `"`); end >= 0 {
+			return v[1 : end+1]
+		}
+	}
+	if i := strings.IndexAny(v, "#;"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
+
+// expandHome replaces a leading "~/" in path with home, as git does for
+// core.excludesFile.
+func expandHome(path, home string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package ignore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// LoadGlobalGitignore
+// ---------------------------------------------------------------------------
+
+func TestGlobalGitignorePathFromGitconfig(t *testing.T) {
+	home := t.TempDir()
+	writeFiles(t, home, map[string]string{
+		".gitconfig": "[user]\n\tname = x\n[core]\n\texcludesFile = \"~/my ignores\" ; comment\n",
+	})
+
+	path, err := globalGitignorePath(home, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "my ignores"), path)
+}
+
+func TestGlobalGitignorePathGitconfigOverridesXDG(t *testing.T) {
+	home, xdg := t.TempDir(), t.TempDir()
+	writeFiles(t, xdg, map[string]string{"git/config": "[core]\nexcludesfile = /from/xdg\n"})
+	writeFiles(t, home, map[string]string{".gitconfig": "[Core]\nExcludesFile = /from/home # trailing\n"})
+
+	path, err := globalGitignorePath(home, xdg)
+	require.NoError(t, err)
+	assert.Equal(t, "/from/home", path)
+}
+
+func TestGlobalGitignorePathFallbacks(t *testing.T) {
+	home, xdg := t.TempDir(), t.TempDir()
+
+	path, err := globalGitignorePath(home, xdg)
+	require.NoError(t, err)
+	assert.Empty(t, path, "no config and no default files")
+
+	writeFiles(t, home, map[string]string{".gitignore_global": "*.swp\n"})
+	path, err = globalGitignorePath(home, xdg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".gitignore_global"), path)
+
+	writeFiles(t, xdg, map[string]string{"git/ignore": ".DS_Store\n"})
+	path, err = globalGitignorePath(home, xdg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(xdg, "git", "ignore"), path, "XDG default wins over ~/.gitignore_global")
+}
+
+func TestLoadGlobalGitignore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	patterns, err := LoadGlobalGitignore()
+	require.NoError(t, err)
+	assert.Nil(t, patterns)
+
+	writeFiles(t, home, map[string]string{
+		".gitconfig":     "[core]\n\texcludesfile = ~/.global-ignore\n",
+		".global-ignore": "# editor files\n*.swp\n.DS_Store\n",
+	})
+	patterns, err = LoadGlobalGitignore()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.swp", ".DS_Store"}, patterns)
+}
+
+// ---------------------------------------------------------------------------
+// LoadGitInfoExclude
+// ---------------------------------------------------------------------------
+
+func TestLoadGitInfoExclude(t *testing.T) {
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		".git/info/exclude": "# git ls-files --others --exclude-from=.git/info/exclude\n*.local\n/scratch/\n",
+	})
+
+	patterns, err := LoadGitInfoExclude(repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.local", "/scratch/"}, patterns)
+}
+
+func TestLoadGitInfoExcludeMissing(t *testing.T) {
+	patterns, err := LoadGitInfoExclude(t.TempDir())
+	require.NoError(t, err, "not a git repository")
+	assert.Nil(t, patterns)
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{".git/HEAD": "ref: refs/heads/main\n"})
+	patterns, err = LoadGitInfoExclude(repo)
+	require.NoError(t, err, "repository without info/exclude")
+	assert.Nil(t, patterns)
+}
+
+func TestLoadGitInfoExcludeWorktree(t *testing.T) {
+	main := t.TempDir()
+	writeFiles(t, main, map[string]string{
+		".git/info/exclude":           "*.local\n",
+		".git/worktrees/wt/commondir": "../..\n",
+	})
+	wt := t.TempDir()
+	writeFiles(t, wt, map[string]string{
+		".git": "gitdir: " + filepath.Join(main, ".git", "worktrees", "wt") + "\n",
+	})
+
+	patterns, err := LoadGitInfoExclude(wt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.local"}, patterns)
+}
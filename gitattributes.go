@@ -0,0 +1,144 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exportIgnoreAttr is the .gitattributes attribute that makes git archive
+// leave matching paths out of the archive.
+const exportIgnoreAttr = "export-ignore"
+
+// NewMatcherFromGitAttributes compiles the export-ignore rules of the
+// .gitattributes file at path into a Matcher, so release tooling can
+// reproduce which paths git archive would leave out without shelling out to
+// git. A path is ignored if its last matching line sets export-ignore; lines
+// that unset it ("-export-ignore" or "!export-ignore") become negations, and
+// lines that do not mention it are skipped.
+//
+// Quoted patterns and [attr] macro definitions are understood: a line using
+// a macro that sets export-ignore counts as setting it.
+func NewMatcherFromGitAttributes(path string) (*Matcher, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	patterns, err := parseExportIgnore(f)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	return NewMatcher(patterns)
+}
+
+// parseExportIgnore converts the export-ignore rules in a .gitattributes
+// stream into gitignore patterns.
+func parseExportIgnore(r io.Reader) ([]string, error) {
+	var patterns []string
+	macros := make(map[string]bool) // macro name -> sets export-ignore
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		pattern, attrs, ok := splitAttributesLine(line)
+		if !ok {
+			continue
+		}
+		if name, isMacro := strings.CutPrefix(pattern, "[attr]"); isMacro {
+			if state, ok := exportIgnoreState(attrs, macros); ok {
+				macros[name] = state
+			}
+			continue
+		}
+
+		state, ok := exportIgnoreState(attrs, macros)
+		if !ok {
+			continue
+		}
+		pattern = escapeGitignoreLiteral(pattern)
+		if !state {
+			pattern = "!" + pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// splitAttributesLine splits a .gitattributes line into its pattern, which
+// may be a C-style quoted string, and its attribute tokens.
+func splitAttributesLine(line string) (pattern string, attrs []string, ok bool) {
+	rest := line
+	if line[0] == '"' {
+		end := closingQuote(line)
+		if end < 0 {
+			return "", nil, false
+		}
+		unquoted, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return "", nil, false
+		}
+		pattern, rest = unquoted, line[end+1:]
+	} else {
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return line, nil, true
+		}
+		pattern, rest = line[:i], line[i:]
+	}
+	return pattern, strings.Fields(rest), true
+}
+
+// closingQuote returns the index of the quote closing the string that opens
+// at s[0], or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// exportIgnoreState reports whether attrs set (true) or unset/unspecify
+// (false) export-ignore. ok is false if no token affects it. Later tokens
+// override earlier ones, as in git.
+func exportIgnoreState(attrs []string, macros map[string]bool) (state, ok bool) {
+	for _, a := range attrs {
+		switch {
+		case a == exportIgnoreAttr, strings.HasPrefix(a, exportIgnoreAttr+"="):
+			state, ok = true, true
+		case a == "-"+exportIgnoreAttr, a == "!"+exportIgnoreAttr:
+			state, ok = false, true
+		default:
+			if s, isMacro := macros[a]; isMacro {
+				state, ok = s, true
+			}
+		}
+	}
+	return state, ok
+}
+
+// escapeGitignoreLiteral escapes a leading "!" or "#", which gitignore would
+// otherwise read as a negation or comment; .gitattributes gives neither a
+// special meaning.
+func escapeGitignoreLiteral(pattern string) string {
+	if strings.HasPrefix(pattern, "!") || strings.HasPrefix(pattern, "#") {
+		return `\` + pattern
+	}
+	return pattern
+}
@@ -0,0 +1,74 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// parseExportIgnore
+// ---------------------------------------------------------------------------
+
+func TestParseExportIgnore(t *testing.T) {
+	got, err := parseExportIgnore(strings.NewReader(`# release tarball contents
+*.go text eol=lf
+/.github export-ignore
+docs/ export-ignore linguist-documentation
+"name with space.txt" export-ignore
+*.md export-ignore
+README.md -export-ignore
+CHANGELOG.md !export-ignore
+[attr]dev-only export-ignore diff
+Makefile dev-only
+justfile dev-only -export-ignore
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/.github",
+		"docs/",
+		"name with space.txt",
+		"*.md",
+		"!README.md",
+		"!CHANGELOG.md",
+		"Makefile",
+		"!justfile",
+	}, got)
+}
+
+func TestParseExportIgnoreEscapesLiterals(t *testing.T) {
+	got, err := parseExportIgnore(strings.NewReader(`"!bang" export-ignore
+"#hash" export-ignore
+"unterminated export-ignore
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`\!bang`, `\#hash`}, got)
+}
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromGitAttributes
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromGitAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitattributes")
+	require.NoError(t, os.WriteFile(path, []byte("/testdata export-ignore\n*.md export-ignore\nREADME.md -export-ignore\n*.go diff=golang\n"), 0o644))
+
+	m, err := NewMatcherFromGitAttributes(path)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.MatchDir("testdata"))
+	assert.True(t, m.Match("testdata/fixture.json"))
+	assert.True(t, m.Match("docs/guide.md"))
+	assert.False(t, m.Match("README.md"))
+	assert.False(t, m.Match("main.go"))
+}
+
+func TestNewMatcherFromGitAttributesMissing(t *testing.T) {
+	_, err := NewMatcherFromGitAttributes(filepath.Join(t.TempDir(), ".gitattributes"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
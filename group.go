@@ -0,0 +1,98 @@
+package ignore
+
+import (
+	"errors"
+	"slices"
+)
+
+// MatcherGroup combines several Matchers, such as a global gitignore, a
+// language template, and a project .gitignore, without merging their
+// pattern lists. A path is ignored if any member ignores it. A "!" pattern
+// only negates earlier patterns in its own member: a member that
+// re-includes a path has no say over its siblings, so it cannot un-ignore a
+// path another member ignores.
+//
+// NOT safe for concurrent use. Close closes every member.
+type MatcherGroup struct {
+	matchers []*Matcher
+}
+
+// NewMatcherGroup returns a group over matchers. Their order does not affect
+// the result. The group takes ownership of the matchers.
+func NewMatcherGroup(matchers ...*Matcher) *MatcherGroup {
+	return &MatcherGroup{matchers: matchers}
+}
+
+// Match reports whether the file at path is ignored. Returns false on any
+// error; use MatchResult to distinguish.
+func (g *MatcherGroup) Match(path string) bool {
+	ignored, _ := g.MatchResult(path, false)
+	return ignored
+}
+
+// MatchDir is like Match for a directory path.
+func (g *MatcherGroup) MatchDir(path string) bool {
+	ignored, _ := g.MatchResult(path, true)
+	return ignored
+}
+
+// MatchResult reports whether path is ignored and surfaces any error.
+func (g *MatcherGroup) MatchResult(path string, isDir bool) (bool, error) {
+	code, err := g.matchCode(path, isDir)
+	return code == MatchIgnore, err
+}
+
+// Filter returns the paths that are NOT ignored, in input order. Paths ending
+// with "/" are treated as directories. A path is kept exactly when every
+// member keeps it, so each member filters what the previous one kept, with
+// one batch_filter round-trip per member.
+func (g *MatcherGroup) Filter(paths []string) ([]string, error) {
+	if len(g.matchers) == 0 {
+		return slices.Clone(paths), nil
+	}
+	kept := paths
+	for _, m := range g.matchers {
+		var err error
+		if kept, err = m.Filter(kept); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// FilterParallel is equivalent to Filter; it exists so that MatcherGroup
+// satisfies IMatcher.
+func (g *MatcherGroup) FilterParallel(paths []string) ([]string, error) {
+	return g.Filter(paths)
+}
+
+// matchCode returns MatchIgnore if any member ignores path, or MatchNone. A
+// member's MatchWhitelist only settles the path within that member and
+// counts as no opinion here.
+func (g *MatcherGroup) matchCode(path string, isDir bool) (int, error) {
+	for _, m := range g.matchers {
+		if err := m.checkOpen(); err != nil {
+			return MatchNone, err
+		}
+		if err := m.opts.ctx.Err(); err != nil {
+			return MatchNone, err
+		}
+		code, err := m.matchCode(path, isDir)
+		if err != nil {
+			return MatchNone, err
+		}
+		if code == MatchIgnore {
+			return MatchIgnore, nil
+		}
+	}
+	return MatchNone, nil
+}
+
+// Close closes every member Matcher. Idempotent.
+func (g *MatcherGroup) Close() error {
+	var errs []error
+	for _, m := range g.matchers {
+		errs = append(errs, m.Close())
+	}
+	return errors.Join(errs...)
+}
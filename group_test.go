@@ -0,0 +1,88 @@
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// MatcherGroup
+// ---------------------------------------------------------------------------
+
+func TestMatcherGroupNegationStaysInMember(t *testing.T) {
+	global, err := NewMatcher([]string{"*.swp", ".DS_Store", "*.log"})
+	require.NoError(t, err)
+	project, err := NewMatcher([]string{"build/", "*.tmp", "!keep.tmp", "!app.log"})
+	require.NoError(t, err)
+
+	g := NewMatcherGroup(global, project)
+	defer func() { _ = g.Close() }()
+
+	assert.True(t, g.Match(".DS_Store"), "ignored by the first member only")
+	assert.True(t, g.MatchDir("build"), "ignored by a later member only")
+	assert.True(t, g.Match("server.log"))
+	assert.True(t, g.Match("app.log"), "a sibling's ! must not un-ignore another member's match")
+	assert.True(t, g.Match("x.tmp"))
+	assert.False(t, g.Match("keep.tmp"), "! negates earlier patterns in the same member")
+	assert.False(t, g.Match("main.go"))
+
+	kept, err := g.Filter([]string{"main.go", "app.log", "keep.tmp", "server.log", "build/", "x.swp"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", "keep.tmp"}, kept)
+}
+
+func TestMatcherGroupFilterAgreesWithMatch(t *testing.T) {
+	newGroup := func(reversed bool) *MatcherGroup {
+		a, err := NewMatcher([]string{"*.log", "!keep.log", "tmp/"})
+		require.NoError(t, err)
+		b, err := NewMatcher([]string{"build/", "*.tmp", "!keep.tmp"})
+		require.NoError(t, err)
+		if reversed {
+			return NewMatcherGroup(b, a)
+		}
+		return NewMatcherGroup(a, b)
+	}
+
+	paths := []string{"main.go", "a.log", "keep.log", "build/", "build/x.go", "x.tmp", "keep.tmp", "tmp/", "src/"}
+	for _, reversed := range []bool{false, true} {
+		g := newGroup(reversed)
+		var want []string
+		for _, p := range paths {
+			ignored, err := g.MatchResult(p, strings.HasSuffix(p, "/"))
+			require.NoError(t, err)
+			if !ignored {
+				want = append(want, p)
+			}
+		}
+		kept, err := g.Filter(paths)
+		require.NoError(t, err)
+		assert.Equal(t, want, kept, "reversed=%v", reversed)
+		assert.Equal(t, []string{"main.go", "keep.log", "keep.tmp", "src/"}, kept)
+		require.NoError(t, g.Close())
+	}
+}
+
+func TestMatcherGroupCloseClosesMembers(t *testing.T) {
+	a, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	b, err := NewMatcher(nil)
+	require.NoError(t, err)
+
+	g := NewMatcherGroup(a, b)
+	require.NoError(t, g.Close())
+	assert.True(t, a.closed)
+	assert.True(t, b.closed)
+	require.NoError(t, g.Close(), "Close must be idempotent")
+}
+
+func TestMatcherGroupEmpty(t *testing.T) {
+	g := NewMatcherGroup()
+	assert.False(t, g.Match("anything"))
+	kept, err := g.Filter([]string{"a.log", "b/"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.log", "b/"}, kept)
+	require.NoError(t, g.Close())
+}
@@ -0,0 +1,81 @@
+package ignore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultHealthcheckTimeout bounds Healthcheck, and Engine.Healthcheck when
+// its context has no deadline, so a wedged engine cannot hang a readiness
+// probe.
+const DefaultHealthcheckTimeout = time.Second
+
+// Healthcheck verifies that the default Engine works end to end, compiling
+// the WASM module first if needed; see Engine.Healthcheck. It gives up after
+// DefaultHealthcheckTimeout.
+func Healthcheck() error {
+	eng, err := getEngine()
+	if err != nil {
+		return fmt.Errorf("ignore: healthcheck: %w", err)
+	}
+	return eng.Healthcheck(context.Background())
+}
+
+// Healthcheck exercises the full WASM call path of e: it borrows an instance,
+// compiles a one-pattern matcher, checks one ignored and one kept path,
+// destroys the matcher, and returns the instance. It returns nil on success
+// and a descriptive error otherwise, or when ctx is done first. Without a
+// deadline on ctx, DefaultHealthcheckTimeout applies.
+//
+// WASM calls cannot be interrupted, so on timeout the check keeps running in
+// the background and returns its instance to the pool when it finishes.
+func (e *Engine) Healthcheck(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultHealthcheckTimeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("ignore: healthcheck: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e.healthcheck() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("ignore: healthcheck: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("ignore: healthcheck: %w", ctx.Err())
+	}
+}
+
+// healthcheck runs the probe described on Healthcheck.
+func (e *Engine) healthcheck() error {
+	inst, err := e.getInstance()
+	if err != nil {
+		return err
+	}
+	defer e.putInstance(inst)
+
+	handle, err := createMatcherOnInstance(e, inst, "*.healthcheck")
+	if err != nil {
+		return err
+	}
+	defer destroyMatcherOnInstance(e, inst, handle)
+
+	for path, want := range map[string]int{"probe.healthcheck": MatchIgnore, "probe.txt": MatchNone} {
+		got, err := isMatchOnInstance(e, inst, handle, path, false)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return fmt.Errorf("is_match(%q) returned %d, want %d", path, got, want)
+		}
+	}
+	return nil
+}
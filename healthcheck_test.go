@@ -0,0 +1,36 @@
+package ignore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// Healthcheck
+// ---------------------------------------------------------------------------
+
+func TestHealthcheck(t *testing.T) {
+	require.NoError(t, Healthcheck())
+}
+
+func TestEngineHealthcheck(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+
+	require.NoError(t, eng.Healthcheck(context.Background()))
+	assert.Equal(t, 1, eng.Stats().PoolIdle, "the probe returns its instance")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	assert.ErrorIs(t, eng.Healthcheck(ctx), context.DeadlineExceeded)
+
+	require.NoError(t, eng.Shutdown(context.Background()))
+	err = eng.Healthcheck(context.Background())
+	assert.ErrorIs(t, err, ErrEngineClosed)
+	assert.ErrorContains(t, err, "healthcheck")
+}
@@ -0,0 +1,197 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// gitignoreFile is the name of the per-directory pattern file read by
+// HierarchicalMatcher and GitignoreStack.
+const gitignoreFile = ".gitignore"
+
+// gitignoreLevel is a Matcher compiled from the .gitignore in dir, where dir
+// is slash-separated and relative to the traversal root ("" for the root).
+type gitignoreLevel struct {
+	dir string
+	m   *Matcher
+}
+
+// matchLevels returns the result code for rel from the deepest level with a
+// matching pattern, or MatchNone if no level matches. levels must be ordered
+// shallowest first and each dir must be an ancestor of rel. Each level sees
+// rel relative to its own directory, as git applies a .gitignore.
+func matchLevels(levels []gitignoreLevel, rel string, isDir bool) (int, error) {
+	for i := len(levels) - 1; i >= 0; i-- {
+		l := levels[i]
+		sub := rel
+		if l.dir != "" {
+			sub = strings.TrimPrefix(rel, l.dir+"/")
+		}
+		if err := l.m.checkOpen(); err != nil {
+			return MatchNone, err
+		}
+		code, err := l.m.matchCode(sub, isDir)
+		if err != nil || code != MatchNone {
+			return code, err
+		}
+	}
+	return MatchNone, nil
+}
+
+// HierarchicalMatcher applies every .gitignore found below a root directory
+// the way git does: patterns in a directory's .gitignore apply to paths
+// relative to that directory, deeper files take precedence over shallower
+// ones, and nothing inside an ignored directory can be re-included.
+//
+// NOT safe for concurrent use. Call Close when done.
+type HierarchicalMatcher struct {
+	root   string
+	levels map[string]*Matcher // keyed by slash-separated dir relative to root
+}
+
+// NewHierarchicalMatcher walks the tree rooted at root and compiles a Matcher
+// for every .gitignore it finds. Directories that are themselves ignored are
+// not descended into, so .gitignore files inside them have no effect, as in
+// git. The .git directory is always skipped.
+func NewHierarchicalMatcher(root string) (*HierarchicalMatcher, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: resolving %s: %w", root, err)
+	}
+	h := &HierarchicalMatcher{root: abs, levels: make(map[string]*Matcher)}
+
+	err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		rel := ""
+		if path != abs {
+			r, err := filepath.Rel(abs, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(r)
+			ignored, err := h.matchRel(rel, true)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return fs.SkipDir
+			}
+		}
+
+		m, err := NewMatcherFromFile(filepath.Join(path, gitignoreFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		h.levels[rel] = m
+		return nil
+	})
+	if err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// Match reports whether the file at path is ignored. path may be absolute or
+// relative to the root passed to NewHierarchicalMatcher. Paths outside the
+// root, and errors, report false; use MatchResult to surface errors.
+func (h *HierarchicalMatcher) Match(path string) bool {
+	ignored, _ := h.MatchResult(path, false)
+	return ignored
+}
+
+// MatchDir is like Match for a directory path.
+func (h *HierarchicalMatcher) MatchDir(path string) bool {
+	ignored, _ := h.MatchResult(path, true)
+	return ignored
+}
+
+// MatchResult reports whether path is ignored and surfaces any error.
+func (h *HierarchicalMatcher) MatchResult(path string, isDir bool) (bool, error) {
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(h.root, path)
+		if err != nil {
+			return false, nil
+		}
+		path = rel
+	}
+	rel := filepath.ToSlash(filepath.Clean(path))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return false, nil
+	}
+	return h.matchRel(rel, isDir)
+}
+
+// Filter returns the paths that are NOT ignored, in input order. Paths
+// ending with "/" are treated as directories, as in Matcher.Filter. Each path
+// is checked against every applicable .gitignore, so this makes several
+// is_match calls per path.
+func (h *HierarchicalMatcher) Filter(paths []string) ([]string, error) {
+	var kept []string
+	for _, p := range paths {
+		ignored, err := h.MatchResult(p, strings.HasSuffix(p, "/"))
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+// FilterParallel is equivalent to Filter. It exists so that
+// HierarchicalMatcher satisfies IMatcher; the per-directory Matchers each
+// own a single instance, so there is nothing to parallelize across.
+func (h *HierarchicalMatcher) FilterParallel(paths []string) ([]string, error) {
+	return h.Filter(paths)
+}
+
+// matchRel checks rel and each of its ancestors top-down, so that a path
+// inside an ignored directory is ignored regardless of later negations.
+func (h *HierarchicalMatcher) matchRel(rel string, isDir bool) (bool, error) {
+	parts := strings.Split(rel, "/")
+	levels := make([]gitignoreLevel, 0, len(parts))
+	if m, ok := h.levels[""]; ok {
+		levels = append(levels, gitignoreLevel{dir: "", m: m})
+	}
+
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "/")
+		code, err := matchLevels(levels, prefix, isDir || i < len(parts)-1)
+		if err != nil {
+			return false, err
+		}
+		if code == MatchIgnore {
+			return true, nil
+		}
+		if m, ok := h.levels[prefix]; ok {
+			levels = append(levels, gitignoreLevel{dir: prefix, m: m})
+		}
+	}
+	return false, nil
+}
+
+// Close closes every constituent Matcher. Idempotent.
+func (h *HierarchicalMatcher) Close() error {
+	for dir, m := range h.levels {
+		_ = m.Close()
+		delete(h.levels, dir)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates the given slash-separated files with contents under root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HierarchicalMatcher
+// ---------------------------------------------------------------------------
+
+func TestHierarchicalMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":          "*.log\n/build/\n",
+		"sub/.gitignore":      "!keep.log\n*.tmp\n/local/\n",
+		"sub/deep/.gitignore": "*.tmp\n!wanted.tmp\n",
+		"build/.gitignore":    "!*\n", // inside an ignored dir: never loaded
+	})
+
+	h, err := NewHierarchicalMatcher(root)
+	require.NoError(t, err)
+	defer func() { _ = h.Close() }()
+
+	assert.Len(t, h.levels, 3, "build/.gitignore must not be loaded")
+
+	assert.True(t, h.Match(filepath.Join(root, "debug.log")))
+	assert.True(t, h.Match("sub/debug.log"), "root patterns apply to subdirectories")
+	assert.False(t, h.Match("sub/keep.log"), "deeper negation overrides root pattern")
+	assert.True(t, h.Match("keep.log"), "sub/.gitignore does not apply at the root")
+
+	assert.True(t, h.Match("sub/x.tmp"))
+	assert.False(t, h.Match("x.tmp"))
+	assert.False(t, h.Match("sub/deep/wanted.tmp"), "deepest .gitignore wins")
+
+	assert.True(t, h.MatchDir("sub/local"), "anchored pattern is relative to its .gitignore")
+	assert.False(t, h.MatchDir("local"))
+	assert.True(t, h.Match("build/out.bin"), "contents of ignored dirs stay ignored")
+	assert.True(t, h.Match("build/keep.log"))
+
+	assert.False(t, h.Match(filepath.Join(filepath.Dir(root), "elsewhere.log")), "outside root")
+}
+
+func TestHierarchicalMatcherNoGitignore(t *testing.T) {
+	h, err := NewHierarchicalMatcher(t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = h.Close() }()
+
+	assert.False(t, h.Match("anything.log"))
+	require.NoError(t, h.Close())
+	require.NoError(t, h.Close(), "Close must be idempotent")
+}
@@ -0,0 +1,23 @@
+package ignore
+
+// IMatcher is the matching contract shared by Matcher, SyncMatcher,
+// HierarchicalMatcher, MatcherGroup, CaseFoldMatcher, and ProfilingMatcher.
+// Program against it to swap implementations or to substitute a fake in
+// tests without loading WASM.
+type IMatcher interface {
+	Match(path string) bool
+	MatchDir(path string) bool
+	MatchResult(path string, isDir bool) (bool, error)
+	Filter(paths []string) ([]string, error)
+	FilterParallel(paths []string) ([]string, error)
+	Close() error
+}
+
+var (
+	_ IMatcher = (*Matcher)(nil)
+	_ IMatcher = (*SyncMatcher)(nil)
+	_ IMatcher = (*HierarchicalMatcher)(nil)
+	_ IMatcher = (*MatcherGroup)(nil)
+	_ IMatcher = (*CaseFoldMatcher)(nil)
+	_ IMatcher = (*ProfilingMatcher)(nil)
+)
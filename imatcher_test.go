@@ -0,0 +1,85 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// IMatcher — implementations are interchangeable
+// ---------------------------------------------------------------------------
+
+func TestIMatcherImplementationsAgree(t *testing.T) {
+	patterns := []string{"*.log", "build/", "!keep.log"}
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"),
+		[]byte("*.log\nbuild/\n!keep.log\n"), 0o644))
+
+	newImpls := map[string]func() (IMatcher, error){
+		"Matcher": func() (IMatcher, error) { return NewMatcher(patterns) },
+		"SyncMatcher": func() (IMatcher, error) {
+			return NewSyncMatcher(patterns)
+		},
+		"HierarchicalMatcher": func() (IMatcher, error) {
+			return NewHierarchicalMatcher(root)
+		},
+		"MatcherGroup": func() (IMatcher, error) {
+			// A "!" only negates within its own member, so it stays with
+			// the pattern it re-includes from.
+			a, err := NewMatcher([]string{"build/"})
+			if err != nil {
+				return nil, err
+			}
+			b, err := NewMatcher([]string{"*.log", "!keep.log"})
+			if err != nil {
+				_ = a.Close()
+				return nil, err
+			}
+			return NewMatcherGroup(a, b), nil
+		},
+		"CaseFoldMatcher": func() (IMatcher, error) {
+			m, err := NewMatcher(patterns)
+			if err != nil {
+				return nil, err
+			}
+			return NewCaseFoldMatcher(m), nil
+		},
+		"ProfilingMatcher": func() (IMatcher, error) {
+			m, err := NewMatcher(patterns)
+			if err != nil {
+				return nil, err
+			}
+			return NewProfilingMatcher(m), nil
+		},
+	}
+
+	paths := []string{"main.go", "debug.log", "keep.log", "build/", "src/app.log"}
+	for name, newImpl := range newImpls {
+		t.Run(name, func(t *testing.T) {
+			m, err := newImpl()
+			require.NoError(t, err)
+			defer func() { _ = m.Close() }()
+
+			assert.True(t, m.Match("debug.log"))
+			assert.False(t, m.Match("keep.log"))
+			assert.True(t, m.MatchDir("build"))
+
+			ignored, err := m.MatchResult("main.go", false)
+			require.NoError(t, err)
+			assert.False(t, ignored)
+
+			kept, err := m.Filter(paths)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"main.go", "keep.log"}, kept)
+
+			kept, err = m.FilterParallel(paths)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"main.go", "keep.log"}, kept)
+		})
+	}
+}
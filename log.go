@@ -0,0 +1,44 @@
+package ignore
+
+import (
+	"log/slog"
+	"time"
+)
+
+// debugLogger returns the WithLogger logger if it has debug records enabled
+// for the Matcher's context, and nil otherwise.
+func (o *options) debugLogger() *slog.Logger {
+	if o.logger == nil || !o.logger.Enabled(o.ctx, slog.LevelDebug) {
+		return nil
+	}
+	return o.logger
+}
+
+// logMatch emits the debug record for a single match decision.
+func (m *Matcher) logMatch(l *slog.Logger, path string, isDir bool, code int, err error, elapsed time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("path", path),
+		slog.Bool("is_dir", isDir),
+		slog.String("result", MatchCode(code).String()),
+		slog.Int64("duration_ns", elapsed.Nanoseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.LogAttrs(m.opts.ctx, slog.LevelDebug, "ignore: match", attrs...)
+}
+
+// logFilter emits the debug summary record for a Filter or FilterParallel
+// call.
+func (m *Matcher) logFilter(l *slog.Logger, op string, pathCount, keptCount int, err error, elapsed time.Duration) {
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.Int("path_count", pathCount),
+		slog.Int("kept_count", keptCount),
+		slog.Int64("duration_ns", elapsed.Nanoseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.LogAttrs(m.opts.ctx, slog.LevelDebug, "ignore: filter", attrs...)
+}
@@ -0,0 +1,138 @@
+package ignore
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MatchBuffer is scratch memory inside a Matcher's WASM instance that
+// MatchPrealloc writes paths into. A plain Match allocates and frees a WASM
+// block for every path, which dominates tight loops over millions of paths;
+// a MatchBuffer is allocated once and reused, growing only when a longer
+// path arrives.
+//
+// A MatchBuffer belongs to the Matcher that created it and shares its
+// concurrency rules. Close it when done; closing the Matcher also releases
+// any buffers still open.
+type MatchBuffer struct {
+	m     *Matcher // nil once closed
+	blk   *bufferBlock
+	stack [4]uint64 // is_match arguments and result, reused across calls
+}
+
+// bufferBlock is the WASM block behind a MatchBuffer. The Matcher tracks
+// these rather than the MatchBuffers, which point back to it: a cycle
+// through the Matcher would keep its finalizer from ever running.
+type bufferBlock struct {
+	ptr      uint32
+	cap      uint32
+	released bool // freed by the Matcher's Close
+}
+
+// NewMatchBuffer allocates a MatchBuffer of capacity bytes in m's WASM
+// instance. Size it for the longest path expected; capacity < 1 is treated
+// as 1.
+func (m *Matcher) NewMatchBuffer(capacity int) (*MatchBuffer, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	b := &MatchBuffer{m: m, blk: &bufferBlock{}}
+	if err := b.grow(uint32(max(capacity, 1))); err != nil {
+		return nil, err
+	}
+	m.buffers = append(m.buffers, b.blk)
+	return b, nil
+}
+
+// MatchPrealloc is like MatchResult but writes path into buf instead of a
+// freshly allocated WASM block, so a call makes one FFI round-trip instead
+// of three and, with no path-rewriting options set, allocates nothing in
+// Go. Returns false on any error. Unlike Match it is not logged by
+// WithLogger. It panics if buf was created by another Matcher or closed.
+func (m *Matcher) MatchPrealloc(buf *MatchBuffer, path string, isDir bool) bool {
+	code, _ := m.matchPrealloc(buf, path, isDir)
+	return code == MatchIgnore
+}
+
+// matchPrealloc implements MatchPrealloc, returning the result code.
+func (m *Matcher) matchPrealloc(buf *MatchBuffer, path string, isDir bool) (int, error) {
+	if err := m.checkOpen(); err != nil {
+		return MatchNone, err
+	}
+	if buf.m != m || buf.blk.released {
+		panic("ignore: MatchBuffer used with a Matcher that did not create it, or after Close")
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return MatchNone, err
+	}
+
+	path, ok := m.opts.preparePath(path)
+	if !ok {
+		return MatchNone, nil
+	}
+	if strings.HasSuffix(path, "/") {
+		path = path[:len(path)-1]
+		isDir = true
+	}
+
+	blk := buf.blk
+	if size := uint32(len(path)); size > blk.cap {
+		if err := buf.grow(max(size, 2*blk.cap)); err != nil {
+			return MatchNone, err
+		}
+	}
+	if !m.inst.mod.Memory().WriteString(blk.ptr, path) {
+		return MatchNone, fmt.Errorf("ignore: memory write out of range (ptr=%d, size=%d, mem=%d)",
+			blk.ptr, len(path), m.inst.mod.Memory().Size())
+	}
+
+	stack := buf.stack[:]
+	stack[0], stack[1], stack[2], stack[3] = uint64(m.handle), uint64(blk.ptr), uint64(len(path)), 0
+	if isDir {
+		stack[3] = 1
+	}
+	if err := m.inst.fnIsMatch.CallWithStack(m.eng.ctx, stack); err != nil {
+		m.inst.taint("is_match", err)
+		return MatchNone, fmt.Errorf("ignore: is_match call failed: %w", err)
+	}
+	return isMatchCode(int32(stack[0]), m.handle, path)
+}
+
+// grow replaces the buffer's WASM block with one of size bytes.
+func (b *MatchBuffer) grow(size uint32) error {
+	m, blk := b.m, b.blk
+	m.eng.freeBytes(m.inst, blk.ptr, blk.cap)
+	blk.ptr, blk.cap = 0, 0
+
+	results, err := m.inst.fnAlloc.Call(m.eng.ctx, uint64(size))
+	if err != nil {
+		m.inst.taint("alloc", err)
+		return fmt.Errorf("ignore: alloc failed: %w", err)
+	}
+	if results[0] == 0 {
+		return &AllocError{RequestedBytes: size}
+	}
+	blk.ptr, blk.cap = uint32(results[0]), size
+	return nil
+}
+
+// Close frees the buffer's WASM memory. Idempotent; a closed buffer must not
+// be passed to MatchPrealloc again.
+func (b *MatchBuffer) Close() error {
+	if b.m == nil {
+		return nil
+	}
+	if !b.blk.released {
+		b.m.buffers = slices.DeleteFunc(b.m.buffers, func(o *bufferBlock) bool { return o == b.blk })
+		b.m.releaseBlock(b.blk)
+	}
+	b.m = nil
+	return nil
+}
+
+// releaseBlock frees blk's WASM memory and marks it released.
+func (m *Matcher) releaseBlock(blk *bufferBlock) {
+	m.eng.freeBytes(m.inst, blk.ptr, blk.cap)
+	blk.ptr, blk.cap, blk.released = 0, 0, true
+}
@@ -0,0 +1,86 @@
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// MatchBuffer / MatchPrealloc
+// ---------------------------------------------------------------------------
+
+func TestMatchPreallocAgreesWithMatchResult(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/", "!keep.log", "/docs/**/draft"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	buf, err := m.NewMatchBuffer(4)
+	require.NoError(t, err)
+	defer func() { _ = buf.Close() }()
+
+	long := strings.Repeat("deep/", 100) + "x.log" // forces the buffer to grow
+	for _, path := range []string{"a.log", "keep.log", "build", "build/", "docs/a/b/draft", "main.go", "", long} {
+		for _, isDir := range []bool{false, true} {
+			want, err := m.MatchResult(path, isDir)
+			require.NoError(t, err)
+			assert.Equal(t, want, m.MatchPrealloc(buf, path, isDir), "%q isDir=%v", path, isDir)
+		}
+	}
+	assert.GreaterOrEqual(t, int(buf.blk.cap), len(long))
+
+	_, err = m.matchPrealloc(buf, "\xff.log", false)
+	assert.ErrorIs(t, err, ErrPathEncoding)
+}
+
+func TestMatchPreallocDoesNotAllocate(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	buf, err := m.NewMatchBuffer(64)
+	require.NoError(t, err)
+	defer func() { _ = buf.Close() }()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.MatchPrealloc(buf, "src/deeply/nested/path/main.go", false)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestMatchBufferLifecycle(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	other, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = other.Close() }()
+
+	a, err := m.NewMatchBuffer(16)
+	require.NoError(t, err)
+	b, err := m.NewMatchBuffer(16)
+	require.NoError(t, err)
+	assert.Panics(t, func() { other.MatchPrealloc(a, "x.log", false) }, "a buffer is bound to its Matcher")
+
+	require.NoError(t, a.Close())
+	require.NoError(t, a.Close(), "Close is idempotent")
+	assert.Equal(t, []*bufferBlock{b.blk}, m.buffers)
+	assert.Panics(t, func() { m.MatchPrealloc(a, "x.log", false) }, "a closed buffer cannot be used")
+
+	require.NoError(t, m.Close())
+	assert.True(t, b.blk.released, "closing the Matcher releases its buffers")
+	assert.Zero(t, b.blk.ptr)
+	require.NoError(t, b.Close())
+	assert.Nil(t, b.m)
+}
+
+func TestFinalizerClosesMatcherLeakedWithBuffer(t *testing.T) {
+	assertLeakFinalized(t, func() {
+		m, err := NewMatcher([]string{"*.log"})
+		require.NoError(t, err)
+		buf, err := m.NewMatchBuffer(16)
+		require.NoError(t, err)
+		require.True(t, m.MatchPrealloc(buf, "debug.log", false))
+	})
+}
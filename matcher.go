@@ -1,12 +1,17 @@
 package ignore
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"log"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Sentinel errors corresponding to specific WASM error codes.
@@ -25,9 +30,9 @@ var (
 	// matcher map, typically because the Matcher was already closed.
 	ErrHandleNotFound = errors.New("ignore: matcher handle not found (may have been destroyed)")
 
-	// ErrPatternBuild is returned by NewMatcher when the pattern engine fails
-	// to build. Malformed and non-UTF-8 lines are silently skipped, so this
-	// is rare in practice.
+	// ErrPatternBuild is matched by the *PatternSyntaxError NewMatcher returns
+	// when the pattern engine fails to build. Malformed and non-UTF-8 lines
+	// are silently skipped, so this is rare in practice.
 	ErrPatternBuild = errors.New("ignore: failed to compile patterns")
 
 	// ErrHandleExhausted is returned by NewMatcher when the WASM instance has
@@ -36,14 +41,95 @@ var (
 	ErrHandleExhausted = errors.New("ignore: max matchers created on this instance")
 )
 
+// HandleError reports which call hit one of ErrInvalidHandle, ErrInvalidPath,
+// ErrPathEncoding, or ErrHandleNotFound, so that logs can record the handle
+// and path alongside the classification:
+//
+//	var he *ignore.HandleError
+//	if errors.As(err, &he) {
+//	    slog.Warn("match failed", "handle", he.Handle, "path", he.Path, "err", he.Err)
+//	}
+//
+// errors.Is(err, ErrPathEncoding) and the like keep working.
+type HandleError struct {
+	Handle uint32 // matcher handle passed to WASM
+	Path   string // path being matched; empty for batch calls
+	Func   string // WASM export that reported the error, e.g. "is_match"
+	Err    error  // the sentinel describing the cause
+}
+
+func (e *HandleError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("ignore: %s on handle %d: %s", e.Func, e.Handle, strings.TrimPrefix(e.Err.Error(), "ignore: "))
+	}
+	return fmt.Sprintf("ignore: %s on handle %d, path %q: %s", e.Func, e.Handle, e.Path, strings.TrimPrefix(e.Err.Error(), "ignore: "))
+}
+
+// Is reports whether target is the sentinel in e.Err.
+func (e *HandleError) Is(target error) bool { return target == e.Err }
+
+func (e *HandleError) Unwrap() error { return e.Err }
+
+// ErrMatcherClosed is returned by every Matcher method that has an error
+// result, such as MatchResultCode, Filter, or MarshalText, once the Matcher
+// has been closed; methods that return only a bool, such as Match and
+// MatchAny, report false. With WithStrictMode these calls panic instead.
+// Patterns and Checksum, which can report neither, always panic.
+var ErrMatcherClosed = errors.New("ignore: use of closed Matcher")
+
+// Result codes returned by MatchResultCode, MatchBatch, MatchManyResult, and
+// the other methods that report the three-way result.
+//
+// They are deliberately untyped rather than MatchCode constants. The int
+// results of MatchResultCode and MatchBatch are compared against them
+// throughout callers' code, and typed constants would turn every such
+// comparison and switch into a compile error. Untyped, they match both int
+// and MatchCode values. The cost is that a bare constant prints as a number:
+// fmt.Print(ignore.MatchIgnore) prints 1. Print a MatchCode instead, such as
+// an element of MatchManyResult or MatchCode(code), to get "ignore".
+const (
+	MatchNone      = 0 // path did not match any pattern
+	MatchIgnore    = 1 // path matched an ignore pattern
+	MatchWhitelist = 2 // path matched a negation ("!") pattern
+)
+
+// MatchCode is a result code (MatchNone, MatchIgnore, or MatchWhitelist)
+// with a readable String form for logs and test failures:
+//
+//	code, err := m.MatchResultCode(path, false)
+//	log.Printf("%s: %v", path, ignore.MatchCode(code)) // "debug.log: ignore"
+type MatchCode int
+
+// String returns "none", "ignore", or "whitelist", or "MatchCode(n)" for any
+// other value.
+func (c MatchCode) String() string {
+	switch c {
+	case MatchNone:
+		return "none"
+	case MatchIgnore:
+		return "ignore"
+	case MatchWhitelist:
+		return "whitelist"
+	default:
+		return fmt.Sprintf("MatchCode(%d)", int(c))
+	}
+}
+
 // Matcher holds a borrowed WASM instance with a compiled gitignore pattern set.
 // NOT safe for concurrent use. Call Close when done.
 type Matcher struct {
-	eng      *engine
+	eng      *Engine
 	inst     *wasmInstance
 	handle   uint32
 	patterns string // retained for FilterParallel workers
+	opts     options
 	closed   bool
+	buffers  []*bufferBlock // blocks of open MatchBuffers, released by Close
+
+	// finalizer is set when newMatcher armed finalizeMatcher on m. A Matcher
+	// filled in place by UnmarshalText has none, and Close must not call
+	// SetFinalizer on it: it may be a field inside a larger struct.
+	finalizer bool
 }
 
 // NewMatcher compiles gitignore-style patterns into a Matcher.
@@ -55,35 +141,144 @@ type Matcher struct {
 //   - "!important.log" negation (whitelist)
 //   - "#comment"       ignored line
 func NewMatcher(patterns []string) (*Matcher, error) {
+	return NewMatcherWithOptions(patterns)
+}
+
+// NewMatcherWithOptions is like NewMatcher but applies opts to the returned
+// Matcher. With no options it behaves exactly like NewMatcher.
+func NewMatcherWithOptions(patterns []string, opts ...Option) (*Matcher, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.validate {
+		if errs := ValidatePatterns(patterns); len(errs) > 0 {
+			return nil, &errs[0]
+		}
+	}
+
 	eng, err := getEngine()
 	if err != nil {
 		return nil, err
 	}
+	return newMatcher(eng, strings.Join(patterns, "\x00"), o)
+}
 
-	inst, err := eng.getInstance()
+// NewMatcherWithEngine is like NewMatcher but compiles the patterns on eng,
+// typically one created by NewEngineWithWASM, instead of the default Engine.
+// The Matcher returns its instance to eng on Close. A nil eng means the
+// default Engine.
+func NewMatcherWithEngine(patterns []string, eng *Engine) (*Matcher, error) {
+	if eng == nil {
+		return NewMatcher(patterns)
+	}
+	return newMatcher(eng, strings.Join(patterns, "\x00"), defaultOptions())
+}
+
+// NewIsolatedMatcher is like NewMatcher but gives the Matcher a wazero
+// runtime of its own: the module is compiled afresh for it, shares no memory,
+// pool, or compiled code with any other Matcher, and the runtime is torn down
+// on Close. Use it to keep tenants apart in multi-tenant services. Every call
+// pays the full module compilation, hundreds of milliseconds (see
+// BenchmarkNewIsolatedMatcher), instead of borrowing a pooled instance, so
+// reuse the Matcher rather than building one per request. FilterParallel
+// stays within the isolated runtime's own instances; a Clone gets a runtime
+// of its own too.
+func NewIsolatedMatcher(patterns []string) (*Matcher, error) {
+	return newIsolatedMatcher(strings.Join(patterns, "\x00"), defaultOptions())
+}
+
+func newIsolatedMatcher(joined string, o options) (*Matcher, error) {
+	eng, err := newIsolatedEngine()
 	if err != nil {
 		return nil, err
 	}
+	m, err := newMatcher(eng, joined, o)
+	if err != nil {
+		_ = eng.Shutdown(eng.ctx)
+		return nil, err
+	}
+	return m, nil
+}
 
-	joined := strings.Join(patterns, "\x00")
+// sibling compiles joined with m's options on m's Engine, or on a new
+// isolated Engine if m has one of its own, since closing m shuts that down.
+func (m *Matcher) sibling(joined string) (*Matcher, error) {
+	if m.eng.isolated {
+		return newIsolatedMatcher(joined, m.opts)
+	}
+	return newMatcher(m.eng, joined, m.opts)
+}
+
+// newMatcher borrows an instance from eng and compiles the NUL-joined
+// patterns on it. Used by NewMatcherWithOptions and Clone.
+func newMatcher(eng *Engine, joined string, o options) (*Matcher, error) {
+	if !eng.acquire() {
+		return nil, ErrEngineClosed
+	}
+	inst, err := eng.getInstance()
+	if err != nil {
+		_ = eng.release()
+		return nil, err
+	}
 
-	handle, err := createMatcherOnInstance(eng, inst, joined)
+	handle, err := createMatcherOnInstance(eng, inst, o.compilePatterns(joined))
 	if err != nil {
 		eng.putInstance(inst)
+		_ = eng.release()
 		return nil, err
 	}
 
-	return &Matcher{
+	m := &Matcher{
 		eng:      eng,
 		inst:     inst,
 		handle:   handle,
 		patterns: joined,
-	}, nil
+		opts:     o,
+	}
+	runtime.SetFinalizer(m, finalizeMatcher)
+	m.finalizer = true
+	return m, nil
+}
+
+// ErrMatcherLeaked is reported to the SetTaintedInstanceLogger callback when
+// a Matcher is garbage collected without having been closed.
+var ErrMatcherLeaked = errors.New("ignore: Matcher garbage collected without Close")
+
+// finalizeMatcher is a safety net for Matchers that were never closed: it
+// releases the handle and returns the instance to the pool, then warns so
+// the missing Close can be found. It is not a substitute for Close; the GC
+// may run it late or not at all. A MatchBuffer that is still reachable keeps
+// its Matcher alive, but one leaked along with it does not.
+func finalizeMatcher(m *Matcher) {
+	if m.closed {
+		return
+	}
+	err := fmt.Errorf("%w (handle %d, instance %d)", ErrMatcherLeaked, m.handle, m.inst.id)
+	_ = m.Close()
+	if fn := taintedLogger.Load(); fn != nil {
+		(*fn)(err)
+	} else {
+		log.Println(err)
+	}
+}
+
+// MustNewMatcher is like NewMatcher but panics if the patterns cannot be
+// compiled. It simplifies safe initialization of package-level variables
+// holding a static pattern set:
+//
+//	var defaultMatcher = ignore.MustNewMatcher([]string{"*.log", "build/"})
+func MustNewMatcher(patterns []string) *Matcher {
+	m, err := NewMatcher(patterns)
+	if err != nil {
+		panic("ignore: MustNewMatcher: " + err.Error())
+	}
+	return m
 }
 
 // createMatcherOnInstance compiles patterns on inst and returns the handle.
 // Used by NewMatcher and FilterParallel workers.
-func createMatcherOnInstance(eng *engine, inst *wasmInstance, patterns string) (uint32, error) {
+func createMatcherOnInstance(eng *Engine, inst *wasmInstance, patterns string) (uint32, error) {
 	ptr, size, err := eng.writeString(inst, patterns)
 	if err != nil {
 		return 0, err
@@ -92,7 +287,7 @@ func createMatcherOnInstance(eng *engine, inst *wasmInstance, patterns string) (
 
 	results, err := inst.fnCreateMatcher.Call(eng.ctx, uint64(ptr), uint64(size))
 	if err != nil {
-		inst.tainted = true
+		inst.taint("create_matcher", err)
 		return 0, fmt.Errorf("ignore: create_matcher call failed: %w", err)
 	}
 
@@ -101,7 +296,7 @@ func createMatcherOnInstance(eng *engine, inst *wasmInstance, patterns string) (
 	case -1, -2:
 		return 0, ErrInvalidPath
 	case -3:
-		return 0, ErrPatternBuild
+		return 0, newPatternSyntaxError(patterns)
 	case -4:
 		return 0, ErrHandleExhausted
 	default:
@@ -113,36 +308,304 @@ func createMatcherOnInstance(eng *engine, inst *wasmInstance, patterns string) (
 	return uint32(code), nil
 }
 
-func destroyMatcherOnInstance(eng *engine, inst *wasmInstance, handle uint32) {
+func destroyMatcherOnInstance(eng *Engine, inst *wasmInstance, handle uint32) {
 	if handle == 0 {
 		return
 	}
 	if _, err := inst.fnDestroyMatcher.Call(eng.ctx, uint64(handle)); err != nil {
-		inst.tainted = true
+		inst.taint("destroy_matcher", err)
 	}
 }
 
+// Clone returns an independent Matcher with the same patterns and options,
+// compiled on a separate WASM instance borrowed from the pool. The clone may
+// be used from another goroutine and must be closed separately.
+func (m *Matcher) Clone() (*Matcher, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	return m.sibling(m.patterns)
+}
+
+// Patterns returns the patterns the Matcher was compiled from, in order. The
+// result is a fresh slice on every call; modifying it does not affect the
+// Matcher. Options such as WithCaseInsensitive are not reflected.
+func (m *Matcher) Patterns() []string {
+	m.mustBeOpen()
+	if m.patterns == "" {
+		return nil
+	}
+	return strings.Split(m.patterns, "\x00")
+}
+
+// AddPatterns appends patterns to the Matcher's existing set and recompiles it
+// on the same WASM instance. Later patterns override earlier ones as in a
+// single .gitignore, so a negation added here can re-include a path ignored
+// by the original set. On error the Matcher keeps its previous patterns.
+func (m *Matcher) AddPatterns(patterns []string) error {
+	if err := m.checkOpen(); err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	joined := strings.Join(patterns, "\x00")
+	if m.patterns != "" {
+		joined = m.patterns + "\x00" + joined
+	}
+	return m.recompile(joined)
+}
+
+// Reset replaces the Matcher's patterns with an entirely new set, compiled on
+// the same WASM instance. It is cheaper than Close followed by NewMatcher when
+// a long-running process reloads a changed .gitignore. On error the Matcher
+// keeps its previous patterns and remains usable.
+func (m *Matcher) Reset(patterns []string) error {
+	if err := m.checkOpen(); err != nil {
+		return err
+	}
+	return m.recompile(strings.Join(patterns, "\x00"))
+}
+
+// recompile replaces the Matcher's handle with one compiled from joined.
+// The new handle is created before the old one is destroyed, so a failed
+// compilation leaves the Matcher usable with its previous patterns.
+func (m *Matcher) recompile(joined string) error {
+	handle, err := createMatcherOnInstance(m.eng, m.inst, m.opts.compilePatterns(joined))
+	if err != nil {
+		return err
+	}
+
+	destroyMatcherOnInstance(m.eng, m.inst, m.handle)
+	m.handle = handle
+	m.patterns = joined
+	return nil
+}
+
 // Match reports whether path is ignored. Returns false on any error.
-// Use MatchResult to distinguish "not ignored" from an error.
+// Use MatchResultCode to distinguish "not ignored" from an error.
 func (m *Matcher) Match(path string) bool {
-	matched, _ := m.MatchResult(path, false)
-	return matched
+	code, _ := m.MatchResultCode(path, false)
+	return code == MatchIgnore
 }
 
 // MatchDir reports whether a directory path is ignored. Returns false on any error.
-// Use MatchResult to distinguish "not ignored" from an error.
+// Use MatchResultCode to distinguish "not ignored" from an error.
 func (m *Matcher) MatchDir(path string) bool {
-	matched, _ := m.MatchResult(path, true)
-	return matched
+	code, _ := m.MatchResultCode(path, true)
+	return code == MatchIgnore
+}
+
+// AsFunc returns m.Match as a plain predicate for APIs that accept a
+// func(string) bool. The closure shares m's instance, so it is not safe for
+// concurrent use and reports false once m is closed.
+func (m *Matcher) AsFunc() func(string) bool {
+	return m.Match
+}
+
+// AsDirFunc is like AsFunc but returns m.MatchDir.
+func (m *Matcher) AsDirFunc() func(string) bool {
+	return m.MatchDir
+}
+
+// MatchAny reports whether any of paths is ignored, as by Match. It checks
+// the paths one at a time and stops at the first ignored one, so a pruning
+// check over a large slice can avoid the full Filter round-trip. An empty
+// slice, or a closed Matcher, yields false.
+func (m *Matcher) MatchAny(paths []string) bool {
+	if m.checkOpen() != nil {
+		return false
+	}
+	for _, p := range paths {
+		if m.Match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAll reports whether every one of paths is ignored, as by Match. It is
+// the dual of MatchAny: it stops at the first path that is not ignored, and
+// an empty slice yields true. A closed Matcher yields false.
+func (m *Matcher) MatchAll(paths []string) bool {
+	if m.checkOpen() != nil {
+		return false
+	}
+	for _, p := range paths {
+		if !m.Match(p) {
+			return false
+		}
+	}
+	return true
 }
 
 // MatchResult reports whether path is ignored and surfaces any error.
 //
 //	(true,  nil) — ignored
 //	(false, nil) — not ignored (no match or negation pattern matched)
-//	(false, err) — ErrInvalidHandle, ErrInvalidPath, ErrPathEncoding, or ErrHandleNotFound
+//	(false, err) — ErrInvalidHandle, ErrInvalidPath, ErrPathEncoding, ErrHandleNotFound,
+//	               or ErrMatcherClosed
+//
+// Deprecated: The bool result cannot tell MatchNone from MatchWhitelist. Use
+// MatchResultCode; in v2, MatchResult itself will return the result code.
 func (m *Matcher) MatchResult(path string, isDir bool) (bool, error) {
-	m.mustBeOpen()
+	code, err := m.MatchResultCode(path, isDir)
+	return code == MatchIgnore, err
+}
+
+// MatchResultCode returns the result code for path: MatchIgnore if an ignore
+// pattern matched last, MatchWhitelist if a negation ("!") pattern did, and
+// MatchNone if no pattern matched. On error the code is MatchNone and err is
+// one of ErrInvalidHandle, ErrInvalidPath, ErrPathEncoding, ErrHandleNotFound,
+// or ErrMatcherClosed.
+func (m *Matcher) MatchResultCode(path string, isDir bool) (int, error) {
+	if err := m.checkOpen(); err != nil {
+		return MatchNone, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return MatchNone, err
+	}
+
+	if l := m.opts.debugLogger(); l != nil {
+		start := time.Now()
+		code, err := m.matchCode(path, isDir)
+		m.logMatch(l, path, isDir, code, err, time.Since(start))
+		return code, err
+	}
+	return m.matchCode(path, isDir)
+}
+
+// MatchPath reports whether absPath, an absolute path under root, is ignored.
+// It is the one-call form of making absPath relative to root and calling
+// MatchResult, for Matchers built without WithBaseDir. Returns false on any
+// error; use MatchPathResult to see it.
+func (m *Matcher) MatchPath(root, absPath string, isDir bool) bool {
+	code, _ := m.MatchPathResult(root, absPath, isDir)
+	return code == MatchIgnore
+}
+
+// MatchPathResult is like MatchPath but returns the result code (MatchNone,
+// MatchIgnore, or MatchWhitelist) and any error. absPath is trimmed of root
+// and the following separator, as WithBaseDir does; root itself is
+// MatchNone, and a path outside root is matched unchanged.
+func (m *Matcher) MatchPathResult(root, absPath string, isDir bool) (int, error) {
+	if err := m.checkOpen(); err != nil {
+		return MatchNone, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return MatchNone, err
+	}
+
+	base := options{baseDir: root, windowsPaths: m.opts.windowsPaths}
+	if base.windowsPaths {
+		absPath = NormalizePath(absPath)
+	}
+	if rel, ok := base.trimBaseDir(absPath); ok {
+		if rel == "" {
+			return MatchNone, nil
+		}
+		absPath = rel
+	}
+	return m.matchCode(absPath, isDir)
+}
+
+// MatchResultContext returns the result code (MatchNone, MatchIgnore, or
+// MatchWhitelist) for path, or ctx.Err() if ctx is done before the WASM call
+// starts. On a Matcher built on NewInterruptibleEngine, a call that is
+// already running is abandoned as well, which bounds calls that would
+// otherwise run forever, such as one stuck on a pathological pattern:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+//	defer cancel()
+//	code, err := m.MatchResultContext(ctx, path, false)
+//
+// The returned error wraps ctx.Err(). Interrupting WASM closes the Matcher's
+// instance, so after a call was cut short every later call on m fails; Close
+// m and build a new Matcher. A ctx that is already done is reported without
+// touching WASM, and the Matcher stays usable.
+func (m *Matcher) MatchResultContext(ctx context.Context, path string, isDir bool) (int, error) {
+	if err := m.checkOpen(); err != nil {
+		return MatchNone, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return MatchNone, err
+	}
+	if err := ctx.Err(); err != nil {
+		return MatchNone, err
+	}
+	return m.matchCodeContext(ctx, path, isDir)
+}
+
+// MatchBatch returns the result code (MatchNone, MatchIgnore, or
+// MatchWhitelist) for every path. isDirs must be nil, meaning every path is a
+// file, or have the same length as paths. Paths ending with "/" are always
+// treated as directories.
+//
+// The WASM module has no batch export that preserves the per-path code, so
+// this makes one is_match call per path; prefer Filter when the distinction
+// between "not matched" and "whitelisted" is not needed.
+func (m *Matcher) MatchBatch(paths []string, isDirs []bool) ([]int, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	return matchCodes[int](m, "MatchBatch", paths, isDirs)
+}
+
+// MatchManyResult is MatchBatch returning typed codes, filled into a single
+// slice allocation, for callers that switch on them directly:
+//
+//	codes, err := m.MatchManyResult(paths, nil)
+//	for i, code := range codes {
+//		switch code {
+//		case ignore.MatchIgnore:
+//			...
+//		case ignore.MatchWhitelist:
+//			...
+//		}
+//	}
+//
+// Like MatchResultCode, it returns ErrMatcherClosed on a closed Matcher.
+func (m *Matcher) MatchManyResult(paths []string, isDirs []bool) ([]MatchCode, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	return matchCodes[MatchCode](m, "MatchManyResult", paths, isDirs)
+}
+
+// matchCodes implements MatchBatch and MatchManyResult, reporting errors
+// under the name fn. The caller must have checked that m is open.
+func matchCodes[C ~int](m *Matcher, fn string, paths []string, isDirs []bool) ([]C, error) {
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if isDirs != nil && len(isDirs) != len(paths) {
+		return nil, fmt.Errorf("ignore: %s: got %d isDirs for %d paths", fn, len(isDirs), len(paths))
+	}
+
+	codes := make([]C, len(paths))
+	for i, p := range paths {
+		code, err := m.matchCode(p, isDirs != nil && isDirs[i])
+		if err != nil {
+			return nil, fmt.Errorf("ignore: %s: path %d: %w", fn, i, err)
+		}
+		codes[i] = C(code)
+	}
+	return codes, nil
+}
+
+// matchCode runs is_match for path and returns MatchNone, MatchIgnore, or
+// MatchWhitelist. The caller must have checked that m is open.
+func (m *Matcher) matchCode(path string, isDir bool) (int, error) {
+	return m.matchCodeContext(m.eng.ctx, path, isDir)
+}
+
+// matchCodeContext is matchCode with the WASM calls bound to ctx.
+func (m *Matcher) matchCodeContext(ctx context.Context, path string, isDir bool) (int, error) {
+	path, ok := m.opts.preparePath(path)
+	if !ok {
+		return MatchNone, nil
+	}
 
 	// A trailing "/" unambiguously signals a directory; strip it and force
 	// isDir=true so behaviour is consistent with Filter's auto-detection.
@@ -150,113 +613,264 @@ func (m *Matcher) MatchResult(path string, isDir bool) (bool, error) {
 		path = path[:len(path)-1]
 		isDir = true
 	}
+	return isMatchOnInstanceContext(ctx, m.eng, m.inst, m.handle, path, isDir)
+}
+
+// isMatchOnInstance runs is_match for an already prepared path on
+// inst/handle. Used by Matcher.matchCode and MatchDetail probes.
+func isMatchOnInstance(eng *Engine, inst *wasmInstance, handle uint32, path string, isDir bool) (int, error) {
+	return isMatchOnInstanceContext(eng.ctx, eng, inst, handle, path, isDir)
+}
 
-	ptr, size, err := m.eng.writeString(m.inst, path)
+// isMatchOnInstanceContext is isMatchOnInstance with the alloc and is_match
+// calls bound to ctx. The path buffer is always freed on the engine's own
+// context, so cleanup is not skipped because ctx is done.
+func isMatchOnInstanceContext(ctx context.Context, eng *Engine, inst *wasmInstance, handle uint32, path string, isDir bool) (int, error) {
+	ptr, size, err := eng.writeStringContext(ctx, inst, path)
 	if err != nil {
-		return false, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return MatchNone, fmt.Errorf("ignore: alloc interrupted: %w", ctxErr)
+		}
+		return MatchNone, err
 	}
-	defer m.eng.freeBytes(m.inst, ptr, size)
+	defer eng.freeBytes(inst, ptr, size)
 
 	isDirArg := uint64(0)
 	if isDir {
 		isDirArg = 1
 	}
 
-	results, err := m.inst.fnIsMatch.Call(m.eng.ctx,
-		uint64(m.handle), uint64(ptr), uint64(size), isDirArg)
+	results, err := inst.fnIsMatch.Call(ctx,
+		uint64(handle), uint64(ptr), uint64(size), isDirArg)
 	if err != nil {
-		m.inst.tainted = true
-		return false, fmt.Errorf("ignore: is_match call failed: %w", err)
+		inst.taint("is_match", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return MatchNone, fmt.Errorf("ignore: is_match interrupted: %w", ctxErr)
+		}
+		return MatchNone, fmt.Errorf("ignore: is_match call failed: %w", err)
 	}
 
-	switch int32(results[0]) {
-	case 0: // not matched
-		return false, nil
-	case 1: // ignored
-		return true, nil
-	case 2: // whitelisted (negation pattern)
-		return false, nil
+	return isMatchCode(int32(results[0]), handle, path)
+}
+
+// isMatchCode maps an is_match return value for path on handle to a result
+// code or error.
+func isMatchCode(code int32, handle uint32, path string) (int, error) {
+	var sentinel error
+	switch code {
+	case MatchNone, MatchIgnore, MatchWhitelist:
+		return int(code), nil
 	case -1:
-		return false, ErrInvalidHandle
+		sentinel = ErrInvalidHandle
 	case -2:
-		return false, ErrInvalidPath
+		sentinel = ErrInvalidPath
 	case -3:
-		return false, ErrPathEncoding
+		sentinel = ErrPathEncoding
 	case -4:
-		return false, ErrHandleNotFound
+		sentinel = ErrHandleNotFound
 	default:
-		return false, fmt.Errorf("ignore: is_match returned unexpected code: %d", int32(results[0]))
+		return MatchNone, fmt.Errorf("ignore: is_match returned unexpected code: %d", code)
 	}
+	return MatchNone, &HandleError{Handle: handle, Path: path, Func: "is_match", Err: sentinel}
 }
 
 // Filter returns paths that are NOT ignored. Uses a single batch_filter FFI
 // round-trip. Paths ending with "/" are treated as directories.
-func (m *Matcher) Filter(paths []string) ([]string, error) {
-	m.mustBeOpen()
+func (m *Matcher) Filter(paths []string) (kept []string, err error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if l := m.opts.debugLogger(); l != nil {
+		defer func(start time.Time) {
+			m.logFilter(l, "Filter", len(paths), len(kept), err, time.Since(start))
+		}(time.Now())
+	}
 
 	if len(paths) == 0 {
 		return nil, nil
 	}
+	return m.filter(paths)
+}
 
-	return batchFilterOnInstance(m.eng, m.inst, m.handle, paths)
+// FilterReuse is like Filter but appends the kept paths to result[:0],
+// reusing its backing array, and returns the extended slice. A hot loop that
+// passes each call's result back in stops allocating a result slice once it
+// is large enough:
+//
+//	var kept []string
+//	for batch := range batches {
+//	    kept, err = m.FilterReuse(batch, kept)
+//	}
+//
+// result may be paths itself to filter in place. Unlike Filter, the result is
+// result[:0] rather than nil when nothing is kept. The kept strings still
+// come from one new allocation per call, which they share. Not logged by
+// WithLogger.
+func (m *Matcher) FilterReuse(paths, result []string) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return result[:0], nil
+	}
+	return m.filterInto(paths, result[:0])
+}
+
+// FilterWithCap is like FilterReuse with a new result slice of capacity
+// resultCap, for callers that know roughly how many paths will be kept.
+func (m *Matcher) FilterWithCap(paths []string, resultCap int) ([]string, error) {
+	return m.FilterReuse(paths, make([]string, 0, max(resultCap, 0)))
+}
+
+// FilterPaths is a variadic form of Filter for a handful of literal paths:
+//
+//	kept, err := m.FilterPaths("a.log", "b.go", "build/")
+func (m *Matcher) FilterPaths(paths ...string) ([]string, error) {
+	return m.Filter(paths)
+}
+
+// filter implements Filter once the open and context checks have passed.
+func (m *Matcher) filter(paths []string) ([]string, error) {
+	return m.filterInto(paths, nil)
+}
+
+// filterInto is filter appending the kept paths to dst.
+func (m *Matcher) filterInto(paths, dst []string) ([]string, error) {
+	if m.opts.rewritesPaths() {
+		return m.filterPrepared(paths, dst, func(paths []string) ([]string, error) {
+			return batchFilterOnInstance(m.eng, m.inst, m.handle, paths, nil)
+		})
+	}
+	return batchFilterOnInstance(m.eng, m.inst, m.handle, paths, dst)
 }
 
-// batchFilterOnInstance runs batch_filter on inst/handle. Used by Filter and FilterParallel.
-func batchFilterOnInstance(eng *engine, inst *wasmInstance, handle uint32, paths []string) ([]string, error) {
-	blob := strings.Join(paths, "\x00")
+// filterPrepared runs filter over the WASM-facing form of paths (see
+// options.preparePath) and appends the caller's original strings for the
+// kept entries to dst.
+// Paths that preparePath excludes from matching are always kept.
+func (m *Matcher) filterPrepared(paths, dst []string, filter func([]string) ([]string, error)) ([]string, error) {
+	sent := make([]string, 0, len(paths))
+	skipped := make([]bool, len(paths))
+	for i, p := range paths {
+		prepared, ok := m.opts.preparePath(p)
+		if !ok {
+			skipped[i] = true
+			continue
+		}
+		sent = append(sent, prepared)
+	}
 
-	pathsPtr, pathsSize, err := eng.writeString(inst, blob)
+	var kept []string
+	if len(sent) > 0 {
+		var err error
+		if kept, err = filter(sent); err != nil {
+			return nil, err
+		}
+	}
+
+	mask := keptMask(sent, kept)
+	out := dst
+	j := 0
+	for i, p := range paths {
+		if skipped[i] {
+			out = append(out, p)
+			continue
+		}
+		if mask[j] {
+			out = append(out, p)
+		}
+		j++
+	}
+	return out, nil
+}
+
+// keptMask reports, for each entry of sent, whether it survived filtering.
+// kept must be the batch_filter result for sent, which is always an in-order
+// subsequence of it. Identical inputs always produce identical results, so a
+// greedy two-pointer walk is exact even with duplicate paths.
+func keptMask(sent, kept []string) []bool {
+	mask := make([]bool, len(sent))
+	j := 0
+	for i, p := range sent {
+		if j < len(kept) && kept[j] == p {
+			mask[i] = true
+			j++
+		}
+	}
+	return mask
+}
+
+// batchFilterOnInstance runs batch_filter on inst/handle and appends the kept
+// paths to dst. Used by Filter and FilterParallel.
+func batchFilterOnInstance(eng *Engine, inst *wasmInstance, handle uint32, paths, dst []string) ([]string, error) {
+	_, kept, err := batchFilterCall(eng, inst, handle, paths, dst, true)
+	return kept, err
+}
+
+// batchFilterCall runs batch_filter and returns the number of kept paths
+// together with dst, to which the kept paths are appended. When collect is
+// false the result buffer is freed without being copied out of WASM memory
+// and dst is returned unchanged.
+func batchFilterCall(eng *Engine, inst *wasmInstance, handle uint32, paths, dst []string, collect bool) (int, []string, error) {
+	pathsPtr, pathsSize, err := eng.writeStrings(inst, paths, 0)
 	if err != nil {
-		return nil, fmt.Errorf("ignore: failed to write paths to wasm memory: %w", err)
+		return 0, nil, fmt.Errorf("ignore: failed to write paths to wasm memory: %w", err)
 	}
 	defer eng.freeBytes(inst, pathsPtr, pathsSize)
 
 	infoResults, err := inst.fnAlloc.Call(eng.ctx, 8) // 8 bytes: result_ptr i32 + result_len i32
 	if err != nil {
-		inst.tainted = true
-		return nil, fmt.Errorf("ignore: failed to allocate result info buffer: %w", err)
+		inst.taint("alloc", err)
+		return 0, nil, fmt.Errorf("ignore: failed to allocate result info buffer: %w", err)
 	}
 	infoPtr := uint32(infoResults[0])
 	if infoPtr == 0 {
-		return nil, fmt.Errorf("ignore: alloc returned null for result info buffer (out of memory)")
+		return 0, nil, fmt.Errorf("ignore: failed to allocate result info buffer: %w", &AllocError{RequestedBytes: 8})
 	}
 	defer eng.freeBytes(inst, infoPtr, 8)
 
 	results, err := inst.fnBatchFilter.Call(eng.ctx,
 		uint64(handle), uint64(pathsPtr), uint64(pathsSize), uint64(infoPtr))
 	if err != nil {
-		inst.tainted = true
-		return nil, fmt.Errorf("ignore: batch_filter call failed: %w", err)
+		inst.taint("batch_filter", err)
+		return 0, nil, fmt.Errorf("ignore: batch_filter call failed: %w", err)
 	}
 
 	count := int32(results[0])
 	switch count {
 	case -1:
-		return nil, ErrInvalidHandle
+		return 0, nil, &HandleError{Handle: handle, Func: "batch_filter", Err: ErrInvalidHandle}
 	case -2:
-		return nil, fmt.Errorf("ignore: batch_filter: invalid result info pointer (internal error)")
+		return 0, nil, fmt.Errorf("ignore: batch_filter: invalid result info pointer (internal error)")
 	case -3:
-		return nil, ErrInvalidPath
+		return 0, nil, &HandleError{Handle: handle, Func: "batch_filter", Err: ErrInvalidPath}
 	case -4:
-		return nil, ErrPathEncoding
+		return 0, nil, &HandleError{Handle: handle, Func: "batch_filter", Err: ErrPathEncoding}
 	case -5:
-		return nil, ErrHandleNotFound
+		return 0, nil, &HandleError{Handle: handle, Func: "batch_filter", Err: ErrHandleNotFound}
 	case -6:
-		return nil, fmt.Errorf("ignore: batch_filter: result exceeds i32::MAX (internal error)")
+		return 0, nil, fmt.Errorf("ignore: batch_filter: result exceeds i32::MAX (internal error)")
 	case -7:
-		return nil, fmt.Errorf("ignore: batch_filter: result_info pointer overflows address space (internal error)")
+		return 0, nil, fmt.Errorf("ignore: batch_filter: result_info pointer overflows address space (internal error)")
 	default:
 		if count < 0 {
-			return nil, fmt.Errorf("ignore: batch_filter returned unexpected error code: %d", count)
+			return 0, nil, fmt.Errorf("ignore: batch_filter returned unexpected error code: %d", count)
 		}
 	}
 	if count == 0 {
-		return nil, nil
+		return 0, dst, nil
 	}
 
 	infoBuf, ok := inst.mod.Memory().Read(infoPtr, 8)
 	if !ok {
-		return nil, fmt.Errorf("ignore: failed to read result info from wasm memory (ptr=%d, mem=%d)",
+		return 0, nil, fmt.Errorf("ignore: failed to read result info from wasm memory (ptr=%d, mem=%d)",
 			infoPtr, inst.mod.Memory().Size())
 	}
 
@@ -264,134 +878,458 @@ func batchFilterOnInstance(eng *engine, inst *wasmInstance, handle uint32, paths
 	resultLen := binary.LittleEndian.Uint32(infoBuf[4:8])
 
 	if resultPtr == 0 || resultLen == 0 {
-		return nil, fmt.Errorf("ignore: batch_filter reported %d kept paths but result buffer is empty", count)
+		return 0, nil, fmt.Errorf("ignore: batch_filter reported %d kept paths but result buffer is empty", count)
 	}
 
-	resultBytes, err := eng.readBytes(inst, resultPtr, resultLen)
+	if !collect {
+		eng.freeBytes(inst, resultPtr, resultLen)
+		return int(count), dst, nil
+	}
+
+	// Convert straight from the memory view: the string is the only copy.
+	view, ok := inst.mod.Memory().Read(resultPtr, resultLen)
+	var result string
+	if ok {
+		result = string(view)
+	}
 	eng.freeBytes(inst, resultPtr, resultLen) // always free, even on read error
-	if err != nil {
-		return nil, fmt.Errorf("ignore: failed to read batch_filter result from wasm memory: %w", err)
+	if !ok {
+		return 0, nil, fmt.Errorf("ignore: failed to read batch_filter result from wasm memory: "+
+			"memory read out of range (ptr=%d, size=%d, mem=%d)", resultPtr, resultLen, inst.mod.Memory().Size())
 	}
 
-	return strings.Split(string(resultBytes), "\x00"), nil
+	dst = slices.Grow(dst, int(count))
+	for {
+		i := strings.IndexByte(result, 0)
+		if i < 0 {
+			break
+		}
+		dst = append(dst, result[:i])
+		result = result[i+1:]
+	}
+	return int(count), append(dst, result), nil
 }
 
-// FilterParallel returns paths that are NOT ignored, splitting the list across
-// runtime.NumCPU() WASM instances and merging results in order.
+// FilterParallel returns paths that are NOT ignored, spreading the list over
+// runtime.NumCPU() WASM instances and merging results in order. The list is
+// cut into several chunks per worker, which the workers pull from a shared
+// queue so that none sits idle while work remains. Inputs shorter than the
+// parallel threshold (see SetParallelThreshold) are filtered serially.
 // Patterns are re-compiled on each worker (~1–10µs each); prefer Filter for
 // small lists (< 10k paths) where parallelism overhead outweighs the savings.
-func (m *Matcher) FilterParallel(paths []string) ([]string, error) {
-	m.mustBeOpen()
+// If any worker or chunk fails, the error is a MultiError holding one error
+// per failure, each naming the worker and chunk index.
+func (m *Matcher) FilterParallel(paths []string) (kept []string, err error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if l := m.opts.debugLogger(); l != nil {
+		defer func(start time.Time) {
+			m.logFilter(l, "FilterParallel", len(paths), len(kept), err, time.Since(start))
+		}(time.Now())
+	}
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if len(paths) < int(parallelThreshold.Load()) {
+		return m.filter(paths)
+	}
+	return m.filterParallelN(m.opts.ctx, paths, 0, nil)
+}
+
+// FilterParallelWithContext is like FilterParallel but stops handing out work
+// once ctx is done: each worker checks ctx before borrowing an instance and
+// again before its batch_filter call, and a worker that finds ctx done skips
+// its chunk. WASM calls already running are not interrupted.
+//
+// If chunks were skipped, the result holds the kept paths of the chunks that
+// did finish, in order, and the error wraps ctx.Err(), so
+// errors.Is(err, context.Canceled) reports a cancellation.
+//
+// The Matcher's WithContext ctx is watched the same way for the whole call,
+// and the error then wraps its ctx.Err() instead.
+func (m *Matcher) FilterParallelWithContext(ctx context.Context, paths []string) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if len(paths) < int(parallelThreshold.Load()) {
+		return m.filter(paths)
+	}
+	return m.filterParallelN(ctx, paths, 0, nil)
+}
+
+// FilterParallelTolerant is like FilterParallel but does not give up when
+// part of the work fails. kept holds the kept paths of every chunk that
+// succeeded, in order, and errs holds one *ChunkError per failed chunk or
+// worker; errs is nil on full success. Each failed chunk's Paths can be
+// retried on their own:
+//
+//	kept, errs := m.FilterParallelTolerant(paths)
+//	for _, err := range errs {
+//	    var ce *ignore.ChunkError
+//	    if errors.As(err, &ce) && ce.Paths != nil {
+//	        retry(ce.Paths)
+//	    }
+//	}
+//
+// The paths of failed chunks are missing from kept, so kept is only the
+// complete result when errs is nil. If the Matcher is closed or its context
+// is done before any work starts, errs holds just that error.
+func (m *Matcher) FilterParallelTolerant(paths []string) (kept []string, errs []error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, []error{err}
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, []error{err}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	workers := 0
+	if len(paths) < int(parallelThreshold.Load()) {
+		workers = 1
+	}
+	run := m.runParallel(m.opts.ctx, paths, workers, nil)
+	if run.err != nil {
+		return nil, []error{run.err}
+	}
+	for _, ce := range run.failures() {
+		errs = append(errs, ce)
+	}
+	return run.merge(), errs
+}
+
+// DefaultParallelThreshold is the default minimum number of paths for which
+// FilterParallel actually fans out. Filter costs roughly 1.3µs per path
+// (BenchmarkFilter100), so a 256-path batch finishes in about the time it
+// takes to borrow extra instances and recompile the patterns on each of them.
+const DefaultParallelThreshold = 256
+
+// chunksPerWorker is how many chunks FilterParallel cuts per worker. Workers
+// pull chunks from a shared queue, so smaller chunks let a worker that
+// finishes early take over work from a slower one, at the cost of one
+// batch_filter call per chunk.
+const chunksPerWorker = 4
+
+var parallelThreshold atomic.Int64
+
+func init() {
+	parallelThreshold.Store(DefaultParallelThreshold)
+}
+
+// SetParallelThreshold sets the minimum number of paths for which
+// FilterParallel splits work across instances; smaller inputs are filtered
+// serially on the Matcher's own instance, as with Filter. n <= 0 disables the
+// fallback. FilterParallelN always honours its explicit worker count. Safe to
+// call concurrently with filtering.
+func SetParallelThreshold(n int) {
+	parallelThreshold.Store(int64(max(n, 0)))
+}
+
+// FilterParallelN is like FilterParallel but splits the work across the given
+// number of WASM instances instead of runtime.NumCPU(). workers is clamped to
+// [1, len(paths)]; zero means runtime.NumCPU(). Use it to cap concurrency on
+// shared machines or to find the sweet spot for a workload.
+func (m *Matcher) FilterParallelN(paths []string, workers int) ([]string, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := m.opts.ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if len(paths) == 0 {
 		return nil, nil
 	}
+	return m.filterParallelN(m.opts.ctx, paths, workers, nil)
+}
 
-	numWorkers := runtime.NumCPU()
-	if numWorkers < 1 {
-		numWorkers = 1
+// filterParallelN implements FilterParallelN once the open and context checks
+// have passed. If onChunk is non-nil it is called with the size of each chunk
+// as soon as that chunk has been filtered. Workers skip their chunk once ctx
+// is done; the kept paths of the other chunks are then returned with an error
+// wrapping ctx.Err().
+func (m *Matcher) filterParallelN(ctx context.Context, paths []string, workers int, onChunk func(n int)) ([]string, error) {
+	run := m.runParallel(ctx, paths, workers, onChunk)
+	if run.err != nil {
+		return nil, run.err
+	}
+	var errs []error
+	for _, ce := range run.failures() {
+		if ce.Chunk < 0 || !ce.skipped {
+			errs = append(errs, ce)
+		}
+	}
+	if err := multiError(errs); err != nil {
+		return nil, err
 	}
-	if numWorkers > len(paths) {
-		numWorkers = len(paths)
+
+	kept := run.merge()
+	if n := run.skippedCount(); n > 0 {
+		return kept, fmt.Errorf("ignore: FilterParallel: %d of %d chunks skipped: %w", n, len(run.chunks), run.skipErr())
 	}
+	return kept, nil
+}
+
+// ChunkError reports a failure of one FilterParallelTolerant worker or chunk.
+// Paths holds the caller's paths of the failed chunk so they can be retried;
+// a worker that failed before taking any chunk has Chunk -1 and no Paths, and
+// its chunks were handled by the other workers.
+type ChunkError struct {
+	Worker int      // index of the worker; 0 runs on the Matcher's own instance
+	Chunk  int      // index of the chunk, or -1
+	Paths  []string // the chunk's paths, in order; nil when Chunk is -1
+	Err    error
 
-	if numWorkers <= 1 {
-		return m.Filter(paths)
+	skipped bool // the chunk was never run because the context was done
+}
+
+func (e *ChunkError) Error() string {
+	if e.Chunk < 0 {
+		return fmt.Sprintf("ignore: FilterParallel worker %d: %v", e.Worker, e.Err)
 	}
+	return fmt.Sprintf("ignore: FilterParallel worker %d, chunk %d: %v", e.Worker, e.Chunk, e.Err)
+}
 
-	chunkSize := (len(paths) + numWorkers - 1) / numWorkers
-	type chunk struct {
-		paths []string
-		index int
+func (e *ChunkError) Unwrap() error { return e.Err }
+
+// parallelRun is the outcome of runParallel: the chunks of the input and, per
+// chunk, either its kept paths or a *ChunkError.
+type parallelRun struct {
+	chunks     [][]string
+	kept       [][]string
+	chunkErrs  []*ChunkError
+	workerErrs []*ChunkError
+	err        error // the input could not be split
+}
+
+// failures returns the worker errors followed by the chunk errors, in chunk
+// order.
+func (r *parallelRun) failures() []*ChunkError {
+	var out []*ChunkError
+	for _, e := range r.workerErrs {
+		if e != nil {
+			out = append(out, e)
+		}
 	}
-	chunks := make([]chunk, 0, numWorkers)
-	for i := 0; i < len(paths); i += chunkSize {
-		end := i + chunkSize
-		if end > len(paths) {
-			end = len(paths)
+	for _, e := range r.chunkErrs {
+		if e != nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (r *parallelRun) skippedCount() int {
+	n := 0
+	for _, e := range r.chunkErrs {
+		if e != nil && e.skipped {
+			n++
+		}
+	}
+	return n
+}
+
+// skipErr returns the context error that made the first skipped chunk skip,
+// or nil if none was skipped.
+func (r *parallelRun) skipErr() error {
+	for _, e := range r.chunkErrs {
+		if e != nil && e.skipped {
+			return e.Err
+		}
+	}
+	return nil
+}
+
+// merge concatenates the kept paths of every successful chunk, in order.
+func (r *parallelRun) merge() []string {
+	total := 0
+	for _, k := range r.kept {
+		total += len(k)
+	}
+	if total == 0 {
+		return nil
+	}
+	merged := make([]string, 0, total)
+	for _, k := range r.kept {
+		merged = append(merged, k...)
+	}
+	return merged
+}
+
+// runParallel filters paths across up to numWorkers instances. numWorkers
+// == 0 means runtime.NumCPU(). The input is cut into chunksPerWorker chunks
+// per worker by the Matcher's ChunkStrategy, and workers pull chunk indices
+// from a shared queue. onChunk, if non-nil, is called from the worker
+// goroutines as each chunk completes successfully. A worker that finds ctx
+// done skips the chunk it pulled. Every chunk ends up with either its kept
+// paths or a *ChunkError.
+func (m *Matcher) runParallel(ctx context.Context, paths []string, numWorkers int, onChunk func(n int)) *parallelRun {
+	if numWorkers == 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	numWorkers = max(min(numWorkers, len(paths)), 1)
+
+	strategy := m.opts.chunkStrategy
+	if strategy == nil {
+		strategy = ChunkStrategyUniform
+	}
+	chunks, err := splitChunks(strategy, paths, min(numWorkers*chunksPerWorker, len(paths)))
+	if err != nil {
+		return &parallelRun{err: err}
+	}
+	numWorkers = min(numWorkers, len(chunks))
+
+	run := &parallelRun{
+		chunks:     chunks,
+		kept:       make([][]string, len(chunks)),
+		chunkErrs:  make([]*ChunkError, len(chunks)),
+		workerErrs: make([]*ChunkError, numWorkers),
+	}
+
+	// Workers pull chunk indices from a shared queue, so one that finishes
+	// early takes more work instead of idling; results stay in chunk order.
+	queue := make(chan int, numWorkers*2)
+	go func() {
+		for idx := range chunks {
+			queue <- idx
+		}
+		close(queue)
+	}()
+
+	// ctx may be a caller's context (FilterParallelWithContext); the
+	// Matcher's own one must stop the run just the same.
+	ctxErr := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return m.opts.ctx.Err()
+	}
+
+	drain := func(worker int, inst *wasmInstance, handle uint32) {
+		for idx := range queue {
+			if err := ctxErr(); err != nil {
+				run.chunkErrs[idx] = &ChunkError{Worker: worker, Chunk: idx, Paths: chunks[idx], Err: err, skipped: true}
+				continue
+			}
+			kept, err := m.filterChunk(inst, handle, chunks[idx])
+			if err != nil {
+				run.chunkErrs[idx] = &ChunkError{Worker: worker, Chunk: idx, Paths: chunks[idx], Err: err}
+				continue
+			}
+			run.kept[idx] = kept
+			if onChunk != nil {
+				onChunk(len(chunks[idx]))
+			}
 		}
-		chunks = append(chunks, chunk{paths: paths[i:end], index: len(chunks)})
 	}
-	numWorkers = len(chunks)
 
-	resultSlices := make([][]string, numWorkers)
-	errs := make([]error, numWorkers)
 	var wg sync.WaitGroup
 	wg.Add(numWorkers)
 
-	go func() { // chunk 0 uses the Matcher's own instance
+	// Worker 0 uses the Matcher's own instance and always drains the queue
+	// to the end, so the feeder finishes even if every other worker fails.
+	go func() {
 		defer wg.Done()
-		resultSlices[0], errs[0] = batchFilterOnInstance(m.eng, m.inst, m.handle, chunks[0].paths)
+		drain(0, m.inst, m.handle)
 	}()
 
-	for i := 1; i < numWorkers; i++ { // chunks 1..N-1 borrow temporary instances
-		go func(idx int) {
+	for i := 1; i < numWorkers; i++ { // workers 1..N-1 borrow temporary instances
+		go func(w int) {
 			defer wg.Done()
+			if ctxErr() != nil {
+				return
+			}
 
 			inst, err := m.eng.getInstance()
 			if err != nil {
-				errs[idx] = fmt.Errorf("ignore: FilterParallel worker %d: failed to get instance: %w", idx, err)
+				run.workerErrs[w] = &ChunkError{Worker: w, Chunk: -1, Err: fmt.Errorf("failed to get instance: %w", err)}
 				return
 			}
 			defer m.eng.putInstance(inst)
 
-			handle, err := createMatcherOnInstance(m.eng, inst, m.patterns)
+			handle, err := createMatcherOnInstance(m.eng, inst, m.opts.compilePatterns(m.patterns))
 			if err != nil {
-				errs[idx] = fmt.Errorf("ignore: FilterParallel worker %d: failed to create matcher: %w", idx, err)
+				run.workerErrs[w] = &ChunkError{Worker: w, Chunk: -1, Err: fmt.Errorf("failed to create matcher: %w", err)}
 				return
 			}
 			defer destroyMatcherOnInstance(m.eng, inst, handle)
 
-			resultSlices[idx], errs[idx] = batchFilterOnInstance(m.eng, inst, handle, chunks[idx].paths)
-			if errs[idx] != nil {
-				errs[idx] = fmt.Errorf("ignore: FilterParallel worker %d: %w", idx, errs[idx])
-			}
+			drain(w, inst, handle)
 		}(i)
 	}
 
 	wg.Wait()
+	return run
+}
 
-	var joinedErr error
-	for _, err := range errs {
-		if err != nil {
-			joinedErr = errors.Join(joinedErr, err)
-		}
+// filterChunk runs batch_filter for one chunk of the caller's paths on
+// inst/handle, applying the Matcher's path-rewriting options first.
+func (m *Matcher) filterChunk(inst *wasmInstance, handle uint32, chunk []string) ([]string, error) {
+	if !m.opts.rewritesPaths() {
+		return batchFilterOnInstance(m.eng, inst, handle, chunk, nil)
 	}
-	if joinedErr != nil {
-		return nil, joinedErr
-	}
-
-	total := 0
-	for _, r := range resultSlices {
-		total += len(r)
-	}
-	if total == 0 {
-		return nil, nil
-	}
-	merged := make([]string, 0, total)
-	for _, r := range resultSlices {
-		merged = append(merged, r...)
-	}
-	return merged, nil
+	return m.filterPrepared(chunk, nil, func(sent []string) ([]string, error) {
+		return batchFilterOnInstance(m.eng, inst, handle, sent, nil)
+	})
 }
 
 // Close destroys the matcher and returns the WASM instance to the pool.
-// Idempotent; any other method called after Close will panic.
+// Idempotent. Afterwards the methods listed under ErrMatcherClosed return
+// that error, and any other method panics.
 func (m *Matcher) Close() error {
 	if m.closed {
 		return nil
 	}
 	m.closed = true
+	if m.finalizer {
+		runtime.SetFinalizer(m, nil)
+		m.finalizer = false
+	}
 
+	for _, blk := range m.buffers {
+		m.releaseBlock(blk)
+	}
+	m.buffers = nil
 	destroyMatcherOnInstance(m.eng, m.inst, m.handle)
 	m.eng.putInstance(m.inst)
 	m.inst = nil
 	m.handle = 0
-	return nil
+	if m.eng.isolated {
+		return m.eng.Shutdown(m.eng.ctx)
+	}
+	return m.eng.release()
 }
 
 func (m *Matcher) mustBeOpen() {
 	if m.closed {
-		panic("ignore: use of closed Matcher")
+		panic(ErrMatcherClosed.Error())
+	}
+}
+
+// checkOpen is mustBeOpen for the methods that report ErrMatcherClosed
+// rather than panicking, unless the Matcher was built with WithStrictMode.
+func (m *Matcher) checkOpen() error {
+	if !m.closed {
+		return nil
+	}
+	if m.opts.strict {
+		panic(ErrMatcherClosed.Error())
 	}
+	return ErrMatcherClosed
 }
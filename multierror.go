@@ -0,0 +1,55 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MultiError collects the errors of several independent units of work, such
+// as the workers of FilterParallel. errors.Is and errors.As look through
+// every element:
+//
+//	var me ignore.MultiError
+//	if errors.As(err, &me) {
+//	    for _, werr := range me.Errors() { log.Print(werr) }
+//	}
+type MultiError []error
+
+// Error lists every error as "ignore: N errors: [err1; err2; ...]".
+func (e MultiError) Error() string {
+	var b strings.Builder
+	if len(e) == 1 {
+		b.WriteString("ignore: 1 error: [")
+	} else {
+		fmt.Fprintf(&b, "ignore: %d errors: [", len(e))
+	}
+	for i, err := range e {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// Unwrap returns the collected errors for errors.Is and errors.As.
+func (e MultiError) Unwrap() []error { return e }
+
+// Errors returns the collected errors.
+func (e MultiError) Errors() []error { return e }
+
+// multiError returns the non-nil errors in errs as a MultiError, or nil if
+// there are none.
+func multiError(errs []error) error {
+	var me MultiError
+	for _, err := range errs {
+		if err != nil {
+			me = append(me, err)
+		}
+	}
+	if me == nil {
+		return nil
+	}
+	return me
+}
@@ -0,0 +1,77 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiError(t *testing.T) {
+	errA := errors.New("ignore: a failed")
+	errB := fmt.Errorf("ignore: FilterParallel worker 2: %w", ErrPathEncoding)
+	err := multiError([]error{nil, errA, nil, errB})
+
+	var me MultiError
+	require.ErrorAs(t, err, &me)
+	assert.Equal(t, []error{errA, errB}, me.Errors())
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, ErrPathEncoding)
+	assert.Equal(t, "ignore: 2 errors: [ignore: a failed; ignore: FilterParallel worker 2: ignore: path is not valid UTF-8]", err.Error())
+
+	assert.Equal(t, "ignore: 1 error: [ignore: a failed]", multiError([]error{errA}).Error())
+	assert.NoError(t, multiError([]error{nil, nil}))
+}
+
+func TestFilterParallelReturnsMultiError(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	// Three workers cut six one-path chunks; invalid UTF-8 in the first and
+	// last makes two of them fail, whichever worker pulls them.
+	paths := []string{"\xff", "a.go", "b.go", "c.go", "d.go", "\xfe"}
+	_, err = m.FilterParallelN(paths, 3)
+	require.ErrorIs(t, err, ErrPathEncoding)
+
+	var me MultiError
+	require.ErrorAs(t, err, &me)
+	require.Len(t, me, 2)
+	assert.Regexp(t, `^ignore: FilterParallel worker \d, chunk 0: `, me[0].Error())
+	assert.Regexp(t, `^ignore: FilterParallel worker \d, chunk 5: `, me[1].Error())
+}
+
+func TestFilterParallelTolerant(t *testing.T) {
+	disableParallelThreshold(t)
+	m, err := NewMatcher([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := []string{"\xff.go", "a.log", "b.go", "c.go", "d.log", "e.go", "f.go", "g.go"}
+	kept, errs := m.FilterParallelTolerant(paths)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], ErrPathEncoding)
+
+	var ce *ChunkError
+	require.ErrorAs(t, errs[0], &ce)
+	assert.Equal(t, 0, ce.Chunk)
+	require.NotEmpty(t, ce.Paths)
+	assert.Equal(t, "\xff.go", ce.Paths[0])
+	assert.Regexp(t, `^ignore: FilterParallel worker \d, chunk 0: `, ce.Error())
+
+	// Every other chunk is filtered as usual.
+	want, err := m.Filter(paths[len(ce.Paths):])
+	require.NoError(t, err)
+	assertStringSliceEqual(t, kept, want)
+
+	kept, errs = m.FilterParallelTolerant(paths[1:])
+	assert.Nil(t, errs, "errs is nil on full success")
+	assertStringSliceEqual(t, kept, []string{"b.go", "c.go", "e.go", "f.go", "g.go"})
+
+	require.NoError(t, m.Close())
+	_, errs = m.FilterParallelTolerant(paths)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrMatcherClosed)
+}
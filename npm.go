@@ -0,0 +1,62 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+)
+
+// npmDefaultPatterns mirrors the rules npm-packlist always applies, written
+// as gitignore patterns.
+var npmDefaultPatterns = []string{
+	".git",
+	".svn",
+	".hg",
+	"CVS",
+	".npmrc",
+	".npmignore",
+	".gitignore",
+	".*.swp",
+	".DS_Store",
+	"._*",
+	"*.orig",
+	"npm-debug.log",
+	"/.lock-wscript",
+	"/.wafpickle-*",
+	"/build/config.gypi",
+	"/node_modules/",
+	"/package-lock.json",
+	"/yarn.lock",
+	"/pnpm-lock.yaml",
+	"/archived-packages/",
+}
+
+// NPMDefaultPatterns returns the exclusions npm applies to every package
+// whether or not it has a .npmignore: version control directories, editor
+// and OS litter, lock files, node_modules, and similar. The slice is a fresh
+// copy and may be modified.
+func NPMDefaultPatterns() []string {
+	return append([]string(nil), npmDefaultPatterns...)
+}
+
+// NewMatcherFromNPMIgnore compiles the rules npm pack uses for the package in
+// projectDir: NPMDefaultPatterns followed by the patterns of projectDir's
+// .npmignore or, if there is none, its .gitignore. With neither file only
+// the defaults apply. Because the project's patterns come last, a negation
+// such as "!CVS" can re-include a default exclusion, which npm itself would
+// not allow for its hard-coded entries.
+func NewMatcherFromNPMIgnore(projectDir string) (*Matcher, error) {
+	patterns := NPMDefaultPatterns()
+	for _, name := range []string{".npmignore", gitignoreFile} {
+		project, err := ParseGitignoreFile(filepath.Join(projectDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, project...)
+		break
+	}
+	return NewMatcher(patterns)
+}
@@ -0,0 +1,65 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// NewMatcherFromNPMIgnore
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherFromNPMIgnore(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		// ignored and kept are checked against the resulting matcher.
+		ignored, kept []string
+	}{
+		{
+			name:    "defaults only",
+			files:   map[string]string{},
+			ignored: []string{".git", "node_modules/x/index.js", "src/a.orig", "package-lock.json"},
+			kept:    []string{"index.js", "lib/node_modules/x.js", "lib/package-lock.json"},
+		},
+		{
+			name:    "falls back to .gitignore",
+			files:   map[string]string{".gitignore": "dist/\n"},
+			ignored: []string{"dist/a.js", ".git"},
+			kept:    []string{"index.js"},
+		},
+		{
+			name:    ".npmignore wins over .gitignore",
+			files:   map[string]string{".gitignore": "dist/\n", ".npmignore": "test/\n"},
+			ignored: []string{"test/a.js", ".gitignore"},
+			kept:    []string{"dist/a.js"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			m, err := NewMatcherFromNPMIgnore(dir)
+			require.NoError(t, err)
+			defer func() { _ = m.Close() }()
+
+			for _, p := range tt.ignored {
+				assert.True(t, m.Match(p), "%s should be ignored", p)
+			}
+			for _, p := range tt.kept {
+				assert.False(t, m.Match(p), "%s should be kept", p)
+			}
+		})
+	}
+}
+
+func TestNPMDefaultPatternsCopy(t *testing.T) {
+	p := NPMDefaultPatterns()
+	require.NotEmpty(t, p)
+	p[0] = "changed"
+	assert.NotEqual(t, "changed", NPMDefaultPatterns()[0])
+	assert.Empty(t, ValidatePatterns(p[1:]))
+}
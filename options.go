@@ -0,0 +1,196 @@
+package ignore
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// Option configures a Matcher created by NewMatcherWithOptions.
+type Option func(*options)
+
+// options holds the per-Matcher settings applied by Option values. The zero
+// value is never used directly; see defaultOptions.
+type options struct {
+	ctx             context.Context
+	caseInsensitive bool
+	baseDir         string // cleaned root; empty when WithBaseDir is not set
+	baseDirStrict   bool
+	validate        bool
+	windowsPaths    bool
+	logger          *slog.Logger
+	strict          bool
+	chunkStrategy   ChunkStrategy // nil means ChunkStrategyUniform
+}
+
+func defaultOptions() options {
+	return options{ctx: context.Background()}
+}
+
+// WithContext binds ctx to the Matcher. Once ctx is done, MatchResult, Filter,
+// and FilterParallel return ctx.Err() instead of calling into WASM, and
+// FilterParallel workers that have not started yet skip their chunk, as with
+// FilterParallelWithContext. Close is unaffected so the instance can always be
+// returned to the pool.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// WithCaseInsensitive makes matching ignore letter case, as on the default
+// macOS and Windows file systems: "*.LOG" matches "debug.log" and vice versa.
+// Patterns and paths are both lower-cased before they reach the WASM module;
+// Filter and FilterParallel still return the caller's original strings.
+func WithCaseInsensitive() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+// WithBaseDir strips root from every path before matching, so absolute paths
+// such as those produced by filepath.WalkDir can be passed straight through:
+// with root "/home/user/project", "/home/user/project/src/main.go" is matched
+// as "src/main.go". root itself is never reported as ignored. Paths that do
+// not lie under root are matched unchanged; see WithBaseDirStrict.
+func WithBaseDir(root string) Option {
+	return func(o *options) {
+		o.baseDir = filepath.Clean(root)
+	}
+}
+
+// WithBaseDirStrict changes how WithBaseDir treats paths outside root: instead
+// of being matched unchanged they are reported as not ignored, without error,
+// and Filter keeps them. It has no effect without WithBaseDir.
+func WithBaseDirStrict() Option {
+	return func(o *options) {
+		o.baseDirStrict = true
+	}
+}
+
+// WithValidation makes the constructor run ValidatePatterns and fail with the
+// first *PatternError instead of letting the WASM module silently skip
+// patterns it cannot compile.
+func WithValidation() Option {
+	return func(o *options) {
+		o.validate = true
+	}
+}
+
+// WithStrictMode makes the Matcher methods that return ErrMatcherClosed (or
+// false) on a closed Matcher panic instead, so that use after Close is
+// caught loudly during development.
+func WithStrictMode() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithChunkStrategy sets how FilterParallel and its variants split paths
+// across workers; see ChunkStrategy. A strategy that returns valid chunks
+// changes only performance, never the result. One that does not tile the
+// paths in order makes every FilterParallel call return an error. A nil
+// strategy means ChunkStrategyUniform.
+func WithChunkStrategy(strategy ChunkStrategy) Option {
+	return func(o *options) {
+		o.chunkStrategy = strategy
+	}
+}
+
+// WithWindowsPathNormalization converts "\\" separators in every path passed
+// to Match, MatchDir, MatchResult, Filter, and FilterParallel to "/" before
+// matching, so paths from filepath.WalkDir on Windows match patterns such as
+// "src/*.go". Pattern text is left alone, and Filter still returns the
+// caller's original strings. Since "\\" is a legal file name character on
+// Unix, the option is best enabled only on Windows.
+func WithWindowsPathNormalization() Option {
+	return func(o *options) {
+		o.windowsPaths = true
+	}
+}
+
+// WithLogger makes the Matcher log its decisions to logger at debug level.
+// Each Match, MatchDir, and MatchResult call emits one record with the
+// attributes path, is_dir, result ("ignore", "whitelist", or "none"), and
+// duration_ns, plus error if the call failed. Filter and FilterParallel emit
+// one summary record per call with op, path_count, kept_count, and
+// duration_ns instead of a record per path. When logger has debug disabled
+// the only cost is an Enabled check. A nil logger disables logging.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// NormalizePath returns path with every "\\" replaced by "/", the form the
+// matcher expects. It is the conversion applied by
+// WithWindowsPathNormalization, exported for callers that normalize paths
+// themselves.
+func NormalizePath(path string) string {
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// compilePatterns returns the form of the joined pattern string that is
+// handed to create_matcher.
+func (o *options) compilePatterns(joined string) string {
+	if o.caseInsensitive {
+		return strings.ToLower(joined)
+	}
+	return joined
+}
+
+// rewritesPaths reports whether preparePath can return something other than
+// its input. When false, callers may skip the prepare/restore round-trip.
+func (o *options) rewritesPaths() bool {
+	return o.caseInsensitive || o.baseDir != "" || o.windowsPaths
+}
+
+// preparePath converts a caller-supplied path into the form passed to WASM.
+// When match is false the path must not be sent to WASM at all and is
+// reported as not ignored (the base directory itself, or a path outside it
+// under WithBaseDirStrict).
+func (o *options) preparePath(path string) (prepared string, match bool) {
+	if o.windowsPaths {
+		path = NormalizePath(path)
+	}
+	if o.baseDir != "" {
+		rel, ok := o.trimBaseDir(path)
+		switch {
+		case ok && rel == "":
+			return path, false
+		case ok:
+			path = rel
+		case o.baseDirStrict:
+			return path, false
+		}
+	}
+	if o.caseInsensitive {
+		path = strings.ToLower(path)
+	}
+	return path, true
+}
+
+// trimBaseDir returns path relative to the base directory, reporting false if
+// path does not lie under it. Both "/" and the OS separator are accepted
+// after the root. Under WithWindowsPathNormalization path has already been
+// normalized, so the root is normalized the same way before comparing.
+func (o *options) trimBaseDir(path string) (string, bool) {
+	base := o.baseDir
+	if o.windowsPaths {
+		base = NormalizePath(base)
+	}
+	rest, ok := strings.CutPrefix(path, base)
+	if !ok {
+		return "", false
+	}
+	if rest == "" || strings.HasSuffix(base, "/") || strings.HasSuffix(base, string(filepath.Separator)) {
+		return rest, true // path is the root itself, or root already ends in a separator
+	}
+	if rest[0] == '/' || rest[0] == filepath.Separator {
+		return rest[1:], true
+	}
+	return "", false // shares a prefix with root, e.g. "/repo-old" under "/repo"
+}
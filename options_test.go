@@ -0,0 +1,274 @@
+package ignore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// NewMatcherWithOptions
+// ---------------------------------------------------------------------------
+
+func TestNewMatcherWithOptionsNoOptions(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log", "!important.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"))
+	assert.False(t, m.Match("important.log"))
+}
+
+func TestWithContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"), "live context must not affect matching")
+
+	cancel()
+
+	_, err = m.MatchResult("debug.log", false)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, m.Match("debug.log"))
+
+	_, err = m.Filter([]string{"debug.log", "main.go"})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = m.FilterParallel([]string{"debug.log", "main.go"})
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.NoError(t, m.Close(), "Close must succeed after the context is done")
+}
+
+// ---------------------------------------------------------------------------
+// WithCaseInsensitive
+// ---------------------------------------------------------------------------
+
+func TestWithCaseInsensitiveMatch(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.LOG", "Build/", "!Important.log"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"), "upper-case pattern should match lower-case path")
+	assert.True(t, m.Match("DEBUG.LOG"))
+	assert.True(t, m.MatchDir("BUILD"))
+	assert.False(t, m.Match("IMPORTANT.LOG"), "negation should also fold case")
+	assert.False(t, m.Match("main.go"))
+}
+
+func TestWithCaseInsensitiveFilterKeepsOriginalCase(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log", "build/"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	paths := []string{"Src/Main.go", "Debug.LOG", "BUILD/", "README.md"}
+	want := []string{"Src/Main.go", "README.md"}
+
+	got, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = m.FilterParallel(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestDefaultMatcherStaysCaseSensitive(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.False(t, m.Match("DEBUG.LOG"))
+}
+
+// ---------------------------------------------------------------------------
+// keptMask
+// ---------------------------------------------------------------------------
+
+func TestKeptMask(t *testing.T) {
+	sent := []string{"a", "b.log", "a", "c", "b.log"}
+	kept := []string{"a", "a", "c"}
+	assert.Equal(t, []bool{true, false, true, true, false}, keptMask(sent, kept))
+}
+
+// ---------------------------------------------------------------------------
+// WithBaseDir
+// ---------------------------------------------------------------------------
+
+func TestWithBaseDirStripsRoot(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"/build", "*.log"}, WithBaseDir("/home/user/project/"))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("/home/user/project/debug.log"))
+	assert.True(t, m.MatchDir("/home/user/project/build"), "anchored pattern should see the relative path")
+	assert.False(t, m.MatchDir("/home/user/project/src/build"))
+	assert.False(t, m.MatchDir("/home/user/project"), "root itself is never ignored")
+	assert.True(t, m.Match("relative.log"), "paths outside root are matched unchanged")
+	assert.True(t, m.Match("/home/user/project-old/x.log"), "sibling with shared prefix is outside root")
+}
+
+func TestWithBaseDirStrict(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithBaseDir("/repo"), WithBaseDirStrict())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("/repo/debug.log"))
+
+	ignored, err := m.MatchResult("/elsewhere/debug.log", false)
+	require.NoError(t, err)
+	assert.False(t, ignored, "paths outside root are not ignored in strict mode")
+
+	paths := []string{"/repo/a.log", "/elsewhere/b.log", "/repo/main.go", "/repo"}
+	want := []string{"/elsewhere/b.log", "/repo/main.go", "/repo"}
+
+	got, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = m.FilterParallel(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// ---------------------------------------------------------------------------
+// WithWindowsPathNormalization
+// ---------------------------------------------------------------------------
+
+func TestWithWindowsPathNormalization(t *testing.T) {
+	patterns := []string{"src/*.go", "build/"}
+
+	plain, err := NewMatcher(patterns)
+	require.NoError(t, err)
+	defer func() { _ = plain.Close() }()
+	assert.False(t, plain.Match(`src\main.go`), "backslash paths do not match without the option")
+
+	m, err := NewMatcherWithOptions(patterns, WithWindowsPathNormalization())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match(`src\main.go`))
+	assert.True(t, m.MatchDir(`build`))
+	assert.True(t, m.Match(`build\out.bin`), "parent directory match through backslash path")
+	assert.False(t, m.Match(`pkg\main.go`))
+
+	paths := []string{`src\main.go`, `pkg\util.go`, `build\out.bin`}
+	want := []string{`pkg\util.go`}
+
+	got, err := m.Filter(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "Filter returns the original strings")
+
+	got, err = m.FilterParallel(paths)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestWithWindowsPathNormalizationBaseDir(t *testing.T) {
+	m, err := NewMatcherWithOptions([]string{"/build"},
+		WithBaseDir(`C:\Users\dev\project`), WithWindowsPathNormalization())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.MatchDir(`C:\Users\dev\project\build`))
+	assert.False(t, m.MatchDir(`C:\Users\dev\project\src\build`))
+}
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, "src/pkg/main.go", NormalizePath(`src\pkg\main.go`))
+	assert.Equal(t, "already/slashed", NormalizePath("already/slashed"))
+	assert.Equal(t, "", NormalizePath(""))
+}
+
+// ---------------------------------------------------------------------------
+// WithLogger
+// ---------------------------------------------------------------------------
+
+// decodeLogRecords parses the JSON lines written by a slog.JSONHandler.
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestWithLoggerMatch(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m, err := NewMatcherWithOptions([]string{"*.log", "!keep.log"}, WithLogger(logger))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	m.Match("debug.log")
+	m.MatchDir("keep.log")
+	_, _ = m.MatchResult("main.go", false)
+
+	records := decodeLogRecords(t, &buf)
+	require.Len(t, records, 3)
+	for i, want := range []struct {
+		path   string
+		isDir  bool
+		result string
+	}{
+		{"debug.log", false, "ignore"},
+		{"keep.log", true, "whitelist"},
+		{"main.go", false, "none"},
+	} {
+		rec := records[i]
+		assert.Equal(t, "DEBUG", rec["level"])
+		assert.Equal(t, want.path, rec["path"])
+		assert.Equal(t, want.isDir, rec["is_dir"])
+		assert.Equal(t, want.result, rec["result"])
+		assert.Contains(t, rec, "duration_ns")
+	}
+}
+
+func TestWithLoggerFilterSummary(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithLogger(logger))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	_, err = m.Filter([]string{"a.go", "b.log", "c.log"})
+	require.NoError(t, err)
+	_, err = m.FilterParallel([]string{"a.go", "b.go"})
+	require.NoError(t, err)
+
+	records := decodeLogRecords(t, &buf)
+	require.Len(t, records, 2, "one summary record per call, not per path")
+	assert.Equal(t, "Filter", records[0]["op"])
+	assert.EqualValues(t, 3, records[0]["path_count"])
+	assert.EqualValues(t, 1, records[0]["kept_count"])
+	assert.Equal(t, "FilterParallel", records[1]["op"])
+	assert.EqualValues(t, 2, records[1]["kept_count"])
+}
+
+func TestWithLoggerDebugDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithLogger(logger))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("debug.log"))
+	_, err = m.Filter([]string{"a.go"})
+	require.NoError(t, err)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,96 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ParseGitignoreFile reads the gitignore-format file at path and returns its
+// patterns as parsed by ParseGitignore.
+func ParseGitignoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	patterns, err := ParseGitignore(f)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: reading %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// ParseGitignore splits gitignore-format text into patterns ready for
+// NewMatcher. It follows git's rules for the file format:
+//
+//   - "\n" and "\r\n" line endings are accepted, and a final line without a
+//     trailing newline is kept
+//   - blank lines and lines starting with "#" are dropped
+//   - escaped leading "\#" and "\!" are kept as written, for the matcher to
+//     read as a literal "#" or "!"
+//   - trailing spaces are removed unless escaped with a backslash ("\ ")
+func ParseGitignore(r io.Reader) ([]string, error) {
+	var patterns []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text() // ScanLines already strips a trailing "\r"
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, trimTrailingSpaces(line))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// NormalizePatterns returns a canonical copy of patterns: comments and blank
+// lines are removed, unescaped trailing spaces are trimmed, and exact
+// duplicates are collapsed to their last occurrence. Because the last
+// matching pattern wins, an earlier copy of a pattern that appears again
+// later can never decide a match, so dropping it preserves semantics.
+// Patterns that are merely equivalent, such as "*.log" and "**/*.log", are
+// left alone.
+func NormalizePatterns(patterns []string) []string {
+	cleaned := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		cleaned = append(cleaned, trimTrailingSpaces(p))
+	}
+
+	last := make(map[string]int, len(cleaned))
+	for i, p := range cleaned {
+		last[p] = i
+	}
+	out := make([]string, 0, len(last))
+	for i, p := range cleaned {
+		if last[p] == i {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// trimTrailingSpaces removes trailing spaces from line, stopping at one that
+// is escaped by an odd number of backslashes.
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		backslashes := 0
+		for i := end - 2; i >= 0 && line[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			break
+		}
+		end--
+	}
+	return line[:end]
+}
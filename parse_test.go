@@ -0,0 +1,98 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ParseGitignore
+// ---------------------------------------------------------------------------
+
+func TestParseGitignoreSkipsCommentsAndBlanks(t *testing.T) {
+	got, err := ParseGitignore(strings.NewReader("# build output\n\nbuild/\n   \n*.log\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build/", "*.log"}, got)
+}
+
+func TestParseGitignoreCRLFAndNoTrailingNewline(t *testing.T) {
+	got, err := ParseGitignore(strings.NewReader("*.log\r\n# comment\r\n!important.log"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.log", "!important.log"}, got)
+}
+
+func TestParseGitignoreKeepsEscapedHash(t *testing.T) {
+	got, err := ParseGitignore(strings.NewReader("\\#file\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\\#file"}, got)
+}
+
+func TestParseGitignoreKeepsEscapedBang(t *testing.T) {
+	got, err := ParseGitignore(strings.NewReader("\\!important\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\\!important"}, got)
+}
+
+func TestParseGitignoreTrailingSpaces(t *testing.T) {
+	got, err := ParseGitignore(strings.NewReader("*.log   \nkeep\\ \nkeep2\\  \nslash\\\\  \n  leading\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.log", "keep\\ ", "keep2\\ ", "slash\\\\", "  leading"}, got)
+}
+
+// ---------------------------------------------------------------------------
+// ParseGitignoreFile
+// ---------------------------------------------------------------------------
+
+func TestParseGitignoreFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\r\n*.log \r\nbuild/"), 0o644))
+
+	got, err := ParseGitignoreFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.log", "build/"}, got)
+
+	_, err = ParseGitignoreFile(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// ---------------------------------------------------------------------------
+// NormalizePatterns
+// ---------------------------------------------------------------------------
+
+func TestNormalizePatterns(t *testing.T) {
+	got := NormalizePatterns([]string{
+		"# generated by tool A",
+		"*.log",
+		"*.log  ",
+		"",
+		"   ",
+		"build/",
+		"!debug.log",
+		"*.log",
+		"**/*.log",
+		`name\ `,
+	})
+	assert.Equal(t, []string{"build/", "!debug.log", "*.log", "**/*.log", `name\ `}, got)
+}
+
+func TestNormalizePatternsPreservesSemantics(t *testing.T) {
+	patterns := []string{"*.log", "!keep.log", "*.log", "build/", "!build/keep/", "build/"}
+	normalized := NormalizePatterns(patterns)
+	assert.Equal(t, []string{"!keep.log", "*.log", "!build/keep/", "build/"}, normalized)
+
+	a, err := NewMatcher(patterns)
+	require.NoError(t, err)
+	defer func() { _ = a.Close() }()
+	b, err := NewMatcher(normalized)
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+
+	for _, p := range []string{"keep.log", "x.log", "build/", "build/keep/", "build/keep/a", "src/main.go"} {
+		assert.Equal(t, a.Match(p), b.Match(p), p)
+	}
+}
@@ -0,0 +1,28 @@
+package ignore
+
+import "strings"
+
+// IsNegationPattern reports whether p re-includes paths, i.e. starts with an
+// unescaped "!". "\!file" is a literal match for "!file", not a negation.
+func IsNegationPattern(p string) bool {
+	return strings.HasPrefix(p, "!")
+}
+
+// IsDirectoryPattern reports whether p only matches directories, i.e. ends
+// with "/" once unescaped trailing spaces are ignored.
+func IsDirectoryPattern(p string) bool {
+	return strings.HasSuffix(trimTrailingSpaces(p), "/")
+}
+
+// IsAnchoredPattern reports whether p matches relative to the directory of
+// its .gitignore rather than at any depth: it starts with "/" or contains a
+// "/" other than a trailing one. A leading "**/" explicitly matches at any
+// depth, so "**/src/build" is not anchored.
+func IsAnchoredPattern(p string) bool {
+	p = strings.TrimPrefix(p, "!")
+	p = strings.TrimSuffix(trimTrailingSpaces(p), "/")
+	if strings.HasPrefix(p, "**/") {
+		return false
+	}
+	return strings.Contains(p, "/")
+}
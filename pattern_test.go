@@ -0,0 +1,49 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ---------------------------------------------------------------------------
+// Pattern inspection
+// ---------------------------------------------------------------------------
+
+func TestPatternInspection(t *testing.T) {
+	tests := []struct {
+		pattern                 string
+		negation, dir, anchored bool
+	}{
+		{"*.log", false, false, false},
+		{"!important.log", true, false, false},
+		{`\!literal`, false, false, false},
+		{"build/", false, true, false},
+		{"build/  ", false, true, false},
+		{"!build/", true, true, false},
+		{"/root.txt", false, false, true},
+		{"/build/", false, true, true},
+		{"src/*.go", false, false, true},
+		{"!docs/internal/", true, true, true},
+		{"**/node_modules/", false, true, false},
+		{"**/src/build", false, false, false},
+		{"src/**/gen", false, false, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.negation, IsNegationPattern(tt.pattern), "IsNegationPattern(%q)", tt.pattern)
+		assert.Equal(t, tt.dir, IsDirectoryPattern(tt.pattern), "IsDirectoryPattern(%q)", tt.pattern)
+		assert.Equal(t, tt.anchored, IsAnchoredPattern(tt.pattern), "IsAnchoredPattern(%q)", tt.pattern)
+	}
+}
+
+func TestAnchoredPatternBehaviour(t *testing.T) {
+	// The classification must agree with how the matcher treats the pattern.
+	for _, p := range []string{"src/*.go", "**/src/*.go", "*.go"} {
+		m, err := NewMatcher([]string{p})
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, !IsAnchoredPattern(p), m.Match("nested/src/main.go"), p)
+		_ = m.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package ignore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PatternSet is a plain-value pattern configuration for config files and API
+// payloads. Unlike a Matcher, which holds WASM state, it can be copied and
+// encoded freely; compile it with NewMatcher when needed. Its JSON form is
+// {"patterns": [...]}, and a bare array of strings is accepted on input:
+//
+//	var cfg struct {
+//	    Ignore ignore.PatternSet `json:"ignore"`
+//	}
+//	err := json.Unmarshal(data, &cfg) // validates every pattern
+//	m, err := cfg.Ignore.NewMatcher()
+type PatternSet struct {
+	Patterns []string `json:"patterns"`
+}
+
+// patternSetJSON has PatternSet's fields without its methods, so encoding it
+// does not recurse.
+type patternSetJSON PatternSet
+
+// MarshalJSON encodes ps as {"patterns": [...]}; a nil list encodes as [].
+func (ps PatternSet) MarshalJSON() ([]byte, error) {
+	if ps.Patterns == nil {
+		ps.Patterns = []string{}
+	}
+	return json.Marshal(patternSetJSON(ps))
+}
+
+// UnmarshalJSON decodes either {"patterns": [...]} or a bare array of
+// strings, then runs ValidatePatterns. If any pattern is invalid it returns
+// the PatternErrors joined, each as a *PatternError, and leaves ps
+// unchanged.
+func (ps *PatternSet) UnmarshalJSON(data []byte) error {
+	var decoded patternSetJSON
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &decoded.Patterns); err != nil {
+			return fmt.Errorf("ignore: decoding pattern set: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("ignore: decoding pattern set: %w", err)
+	}
+
+	if perrs := ValidatePatterns(decoded.Patterns); len(perrs) > 0 {
+		errs := make([]error, len(perrs))
+		for i := range perrs {
+			errs[i] = &perrs[i]
+		}
+		return errors.Join(errs...)
+	}
+	*ps = PatternSet(decoded)
+	return nil
+}
+
+// NewMatcher compiles the set's patterns; it is NewMatcher(ps.Patterns).
+func (ps PatternSet) NewMatcher() (*Matcher, error) {
+	return NewMatcher(ps.Patterns)
+}
@@ -0,0 +1,61 @@
+package ignore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// PatternSet
+// ---------------------------------------------------------------------------
+
+func TestPatternSetJSONRoundTrip(t *testing.T) {
+	type config struct {
+		Name   string     `json:"name"`
+		Ignore PatternSet `json:"ignore"`
+	}
+	in := config{Name: "svc", Ignore: PatternSet{Patterns: []string{"*.log", "!keep.log", "build/"}}}
+
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"svc","ignore":{"patterns":["*.log","!keep.log","build/"]}}`, string(data))
+
+	var out config
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	m, err := out.Ignore.NewMatcher()
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"))
+	assert.False(t, m.Match("keep.log"))
+}
+
+func TestPatternSetJSONForms(t *testing.T) {
+	var ps PatternSet
+	require.NoError(t, json.Unmarshal([]byte(` ["*.tmp", "# comment"]`), &ps))
+	assertStringSliceEqual(t, ps.Patterns, []string{"*.tmp", "# comment"})
+
+	data, err := json.Marshal(PatternSet{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"patterns":[]}`, string(data))
+}
+
+func TestPatternSetJSONValidation(t *testing.T) {
+	ps := PatternSet{Patterns: []string{"keep"}}
+	err := json.Unmarshal([]byte(`{"patterns":["ok","[z-a]","a}b"]}`), &ps)
+	require.Error(t, err)
+
+	var perr *PatternError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, "[z-a]", perr.Pattern)
+	assert.Equal(t, 2, perr.Line)
+	assert.Contains(t, err.Error(), "a}b", "every invalid pattern is reported")
+	assertStringSliceEqual(t, ps.Patterns, []string{"keep"})
+
+	err = json.Unmarshal([]byte(`{"patterns":"*.log"}`), &ps)
+	assert.ErrorContains(t, err, "decoding pattern set")
+}
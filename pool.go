@@ -0,0 +1,113 @@
+package ignore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPoolClosed is returned by MatcherPool.Get after the pool is closed.
+var ErrPoolClosed = errors.New("ignore: matcher pool is closed")
+
+// MatcherPool is a fixed-size pool of Matchers compiled from the same
+// patterns, for services that match from many goroutines at once. Unlike the
+// internal instance pool, its size is explicit: all Matchers are created up
+// front and Get blocks while every one is checked out, so the number of WASM
+// instances never exceeds size. Safe for concurrent use.
+type MatcherPool struct {
+	idle chan *Matcher
+	done chan struct{}
+
+	mu     sync.Mutex // serializes Put against Close
+	closed bool
+}
+
+// NewMatcherPool compiles patterns into size Matchers. size must be at least 1.
+func NewMatcherPool(patterns []string, size int) (*MatcherPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("ignore: NewMatcherPool: size must be at least 1, got %d", size)
+	}
+
+	p := &MatcherPool{
+		idle: make(chan *Matcher, size),
+		done: make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		m, err := NewMatcher(patterns)
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.idle <- m
+	}
+	return p, nil
+}
+
+// Get checks out a Matcher, blocking until one is available. The Matcher
+// must be returned with Put, not closed. Returns ErrPoolClosed once the pool
+// is closed.
+func (p *MatcherPool) Get() (*Matcher, error) {
+	return p.GetContext(context.Background())
+}
+
+// GetContext is like Get but gives up when ctx is done, returning ctx.Err().
+func (p *MatcherPool) GetContext(ctx context.Context) (*Matcher, error) {
+	select {
+	case <-p.done:
+		return nil, ErrPoolClosed
+	default:
+	}
+
+	select {
+	case m := <-p.idle:
+		return m, nil
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Put returns a Matcher obtained from Get. After Close, or if m was not
+// obtained from this pool and the pool is already full, m is closed
+// instead. The Matcher's patterns must not have been changed.
+func (p *MatcherPool) Put(m *Matcher) {
+	if m == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		_ = m.Close()
+		return
+	}
+	select {
+	case p.idle <- m:
+	default:
+		_ = m.Close()
+	}
+}
+
+// Close closes every idle Matcher and makes pending and future Get calls
+// return ErrPoolClosed. Matchers still checked out are closed when they are
+// Put back. Idempotent.
+func (p *MatcherPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+
+	for {
+		select {
+		case m := <-p.idle:
+			_ = m.Close()
+		default:
+			return nil
+		}
+	}
+}
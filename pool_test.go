@@ -0,0 +1,86 @@
+package ignore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// MatcherPool
+// ---------------------------------------------------------------------------
+
+func TestMatcherPoolBoundsConcurrency(t *testing.T) {
+	p, err := NewMatcherPool([]string{"*.log"}, 2)
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+
+	var inUse, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := p.Get()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer p.Put(m)
+
+			n := inUse.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			assert.True(t, m.Match("x.log"))
+			time.Sleep(time.Millisecond)
+			inUse.Add(-1)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestMatcherPoolGetContextTimeout(t *testing.T) {
+	p, err := NewMatcherPool(nil, 1)
+	require.NoError(t, err)
+	defer func() { _ = p.Close() }()
+
+	m, err := p.Get()
+	require.NoError(t, err)
+	defer p.Put(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.GetContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMatcherPoolClose(t *testing.T) {
+	p, err := NewMatcherPool([]string{"*.log"}, 2)
+	require.NoError(t, err)
+
+	out, err := p.Get()
+	require.NoError(t, err)
+
+	require.NoError(t, p.Close())
+	require.NoError(t, p.Close(), "Close must be idempotent")
+
+	_, err = p.Get()
+	assert.ErrorIs(t, err, ErrPoolClosed)
+
+	p.Put(out)
+	assert.True(t, out.closed, "Matchers returned after Close are closed")
+}
+
+func TestNewMatcherPoolInvalidSize(t *testing.T) {
+	_, err := NewMatcherPool(nil, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package ignore
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// ProfileLabelOp is the pprof label key ProfilingMatcher sets around each
+// call. Its value is the method name: "Match", "MatchDir", "MatchResult",
+// "MatchResultCode", "MatchResultContext", "Filter", "FilterWithContext",
+// "FilterParallel", or "FilterParallelWithContext".
+const ProfileLabelOp = "matcher.op"
+
+// ProfilingMatcher wraps a Matcher so that its matching calls run under a
+// runtime/pprof label, ProfileLabelOp, naming the method. CPU and goroutine
+// profiles can then attribute time spent in WASM to the kind of call, e.g.
+// with
+//
+//	go tool pprof -tagfocus=matcher.op=FilterParallel cpu.pprof
+//
+// FilterParallel's worker goroutines inherit the label.
+//
+// pprof.Do sets the goroutine's labels from the context it is given. The
+// methods without a ctx parameter use the Matcher's WithContext ctx, so for
+// the length of the call the goroutine carries only that ctx's labels plus
+// ProfileLabelOp; labels the caller set through its own context are
+// dropped. To keep them, call MatchResultContext, FilterWithContext, or
+// FilterParallelWithContext with the caller's ctx: the label is added on
+// top of that ctx's labels, which are also what the goroutine is restored
+// to afterwards.
+//
+// Every other Matcher method is promoted unchanged and unlabelled. pprof.Do
+// allocates, so use the wrapper where profiling matters more than the last
+// nanosecond per call. Concurrency and Close follow the wrapped Matcher.
+type ProfilingMatcher struct {
+	*Matcher
+}
+
+// NewProfilingMatcher returns m wrapped in a ProfilingMatcher. The wrapper
+// shares m; closing either closes both.
+func NewProfilingMatcher(m *Matcher) *ProfilingMatcher {
+	return &ProfilingMatcher{Matcher: m}
+}
+
+// Match is Matcher.Match under the label matcher.op=Match.
+func (p *ProfilingMatcher) Match(path string) (ignored bool) {
+	p.do("Match", func(context.Context) { ignored = p.Matcher.Match(path) })
+	return ignored
+}
+
+// MatchDir is Matcher.MatchDir under the label matcher.op=MatchDir.
+func (p *ProfilingMatcher) MatchDir(path string) (ignored bool) {
+	p.do("MatchDir", func(context.Context) { ignored = p.Matcher.MatchDir(path) })
+	return ignored
+}
+
+// MatchResult is Matcher.MatchResult under the label matcher.op=MatchResult.
+func (p *ProfilingMatcher) MatchResult(path string, isDir bool) (ignored bool, err error) {
+	p.do("MatchResult", func(context.Context) { ignored, err = p.Matcher.MatchResult(path, isDir) })
+	return ignored, err
+}
+
+// MatchResultCode is Matcher.MatchResultCode under the label
+// matcher.op=MatchResultCode.
+func (p *ProfilingMatcher) MatchResultCode(path string, isDir bool) (code int, err error) {
+	p.do("MatchResultCode", func(context.Context) { code, err = p.Matcher.MatchResultCode(path, isDir) })
+	return code, err
+}
+
+// MatchResultContext is Matcher.MatchResultContext under the label
+// matcher.op=MatchResultContext, added to the labels of ctx.
+func (p *ProfilingMatcher) MatchResultContext(ctx context.Context, path string, isDir bool) (code int, err error) {
+	p.doContext(ctx, "MatchResultContext", func(ctx context.Context) {
+		code, err = p.Matcher.MatchResultContext(ctx, path, isDir)
+	})
+	return code, err
+}
+
+// Filter is Matcher.Filter under the label matcher.op=Filter.
+func (p *ProfilingMatcher) Filter(paths []string) (kept []string, err error) {
+	p.do("Filter", func(context.Context) { kept, err = p.Matcher.Filter(paths) })
+	return kept, err
+}
+
+// FilterWithContext is Matcher.FilterWithContext under the label
+// matcher.op=FilterWithContext, added to the labels of ctx.
+func (p *ProfilingMatcher) FilterWithContext(ctx context.Context, paths []string) (kept []string, err error) {
+	p.doContext(ctx, "FilterWithContext", func(ctx context.Context) {
+		kept, err = p.Matcher.FilterWithContext(ctx, paths)
+	})
+	return kept, err
+}
+
+// FilterParallel is Matcher.FilterParallel under the label
+// matcher.op=FilterParallel.
+func (p *ProfilingMatcher) FilterParallel(paths []string) (kept []string, err error) {
+	p.do("FilterParallel", func(context.Context) { kept, err = p.Matcher.FilterParallel(paths) })
+	return kept, err
+}
+
+// FilterParallelWithContext is Matcher.FilterParallelWithContext under the
+// label matcher.op=FilterParallelWithContext, added to the labels of ctx.
+// The worker goroutines inherit both.
+func (p *ProfilingMatcher) FilterParallelWithContext(ctx context.Context, paths []string) (kept []string, err error) {
+	p.doContext(ctx, "FilterParallelWithContext", func(ctx context.Context) {
+		kept, err = p.Matcher.FilterParallelWithContext(ctx, paths)
+	})
+	return kept, err
+}
+
+// do runs fn with the ProfileLabelOp label set to op, on top of the labels
+// of the Matcher's context.
+func (p *ProfilingMatcher) do(op string, fn func(context.Context)) {
+	p.doContext(p.opts.ctx, op, fn)
+}
+
+// doContext runs fn with the ProfileLabelOp label set to op, on top of the
+// labels of ctx.
+func (p *ProfilingMatcher) doContext(ctx context.Context, op string, fn func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(ProfileLabelOp, op), fn)
+}
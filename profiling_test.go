@@ -0,0 +1,91 @@
+package ignore
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ProfilingMatcher
+// ---------------------------------------------------------------------------
+
+func TestProfilingMatcherDelegates(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	p := NewProfilingMatcher(m)
+	defer func() { _ = p.Close() }()
+
+	assert.True(t, p.Match("debug.log"))
+	assert.True(t, p.MatchDir("build"))
+	ignored, err := p.MatchResult("main.go", false)
+	require.NoError(t, err)
+	assert.False(t, ignored)
+
+	kept, err := p.Filter([]string{"a.go", "b.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, kept)
+	kept, err = p.FilterParallel([]string{"a.go", "b.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go"}, kept)
+
+	assertStringSliceEqual(t, p.Patterns(), []string{"*.log", "build/"})
+}
+
+func TestProfilingMatcherLabels(t *testing.T) {
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("service", "indexer"))
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithContext(ctx))
+	require.NoError(t, err)
+	p := NewProfilingMatcher(m)
+	defer func() { _ = p.Close() }()
+
+	var op, service string
+	p.do("Filter", func(ctx context.Context) {
+		op, _ = pprof.Label(ctx, ProfileLabelOp)
+		service, _ = pprof.Label(ctx, "service")
+	})
+	assert.Equal(t, "Filter", op)
+	assert.Equal(t, "indexer", service, "labels from the Matcher's context are kept")
+}
+
+func TestProfilingMatcherKeepsCallerLabels(t *testing.T) {
+	disableParallelThreshold(t)
+
+	// The chunk strategy runs on the calling goroutine, inside the labelled
+	// call, so the goroutine profile taken there shows its live labels.
+	var profile strings.Builder
+	dumpLabels := func(paths []string, n int) [][]string {
+		_ = pprof.Lookup("goroutine").WriteTo(&profile, 1)
+		return ChunkStrategyUniform(paths, n)
+	}
+	m, err := NewMatcherWithOptions([]string{"*.log"}, WithChunkStrategy(dumpLabels))
+	require.NoError(t, err)
+	p := NewProfilingMatcher(m)
+	defer func() { _ = p.Close() }()
+
+	pprof.Do(context.Background(), pprof.Labels("request", "42"), func(ctx context.Context) {
+		var op, request string
+		p.doContext(ctx, "FilterWithContext", func(ctx context.Context) {
+			op, _ = pprof.Label(ctx, ProfileLabelOp)
+			request, _ = pprof.Label(ctx, "request")
+		})
+		assert.Equal(t, "FilterWithContext", op)
+		assert.Equal(t, "42", request, "the caller's labels survive the call")
+
+		kept, err := p.FilterWithContext(ctx, []string{"a.go", "b.log"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go"}, kept)
+		kept, err = p.FilterParallelWithContext(ctx, []string{"a.go", "b.log"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go"}, kept)
+		assert.Contains(t, profile.String(), `"matcher.op":"FilterParallelWithContext"`)
+		assert.Contains(t, profile.String(), `"request":"42"`, "the caller's label must be live during the call")
+		code, err := p.MatchResultContext(ctx, "b.log", false)
+		require.NoError(t, err)
+		assert.Equal(t, MatchIgnore, code)
+	})
+}
@@ -0,0 +1,73 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrModuleNotFound is returned by ModuleRegistry.Get for a version that is
+// not registered.
+var ErrModuleNotFound = errors.New("ignore: module version not registered")
+
+// ModuleRegistry keeps several compiled matcher modules side by side, keyed
+// by a caller-chosen version string, so a new build of the Rust ignore crate
+// can be rolled out without restarting the process. Callers pick a version
+// with Get and compile patterns on it with NewMatcherWithEngine:
+//
+//	eng, err := reg.Get("v2")
+//	...
+//	m, err := ignore.NewMatcherWithEngine(patterns, eng)
+//
+// Each registered Engine is reference counted: the registry holds one
+// reference and every open Matcher on it holds another. Replacing a version
+// with Register, or removing it with Unregister, drops the registry's
+// reference, and the Engine is shut down as soon as its last Matcher is
+// closed. A Matcher created on an Engine that has already been dropped this
+// way fails with ErrEngineClosed; call Get again for the current one.
+//
+// The zero value is an empty registry ready to use. A ModuleRegistry is safe
+// for concurrent use.
+type ModuleRegistry struct {
+	engines sync.Map // version string -> *Engine
+}
+
+// Register compiles wasmBytes with opts, like NewEngineWithWASM, and makes
+// it the Engine for version, returning it. An Engine already registered
+// under version is replaced and shut down once its Matchers are closed. If
+// wasmBytes cannot be compiled, the registry is left unchanged.
+func (r *ModuleRegistry) Register(version string, wasmBytes []byte, opts ...EngineOption) (*Engine, error) {
+	eng, err := NewEngineWithWASM(wasmBytes, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: registering module %q: %w", version, err)
+	}
+	eng.counted = true
+	eng.refs.Store(1)
+
+	if old, loaded := r.engines.Swap(version, eng); loaded {
+		_ = old.(*Engine).release()
+	}
+	return eng, nil
+}
+
+// Get returns the Engine registered for version, or an error wrapping
+// ErrModuleNotFound.
+func (r *ModuleRegistry) Get(version string) (*Engine, error) {
+	eng, ok := r.engines.Load(version)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrModuleNotFound, version)
+	}
+	return eng.(*Engine), nil
+}
+
+// Unregister removes version from the registry. Its Engine is shut down
+// right away if no Matcher is using it, otherwise when the last one is
+// closed; the error is that of an immediate shutdown. Unregistering an
+// unknown version is a no-op.
+func (r *ModuleRegistry) Unregister(version string) error {
+	eng, loaded := r.engines.LoadAndDelete(version)
+	if !loaded {
+		return nil
+	}
+	return eng.(*Engine).release()
+}
@@ -0,0 +1,101 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ModuleRegistry
+// ---------------------------------------------------------------------------
+
+func TestModuleRegistryGet(t *testing.T) {
+	var reg ModuleRegistry
+	defer func() { _ = reg.Unregister("v1") }()
+
+	_, err := reg.Get("v1")
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+	assert.ErrorContains(t, err, `"v1"`)
+
+	eng, err := reg.Register("v1", matcherWasm)
+	require.NoError(t, err)
+
+	got, err := reg.Get("v1")
+	require.NoError(t, err)
+	assert.Same(t, eng, got)
+
+	m, err := NewMatcherWithEngine([]string{"*.log"}, got)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("debug.log"))
+}
+
+func TestModuleRegistryReplaceKeepsOpenMatchers(t *testing.T) {
+	var reg ModuleRegistry
+	defer func() { _ = reg.Unregister("v1") }()
+
+	old, err := reg.Register("v1", matcherWasm)
+	require.NoError(t, err)
+	m, err := NewMatcherWithEngine([]string{"*.log"}, old)
+	require.NoError(t, err)
+
+	current, err := reg.Register("v1", matcherWasm)
+	require.NoError(t, err)
+	require.NotSame(t, old, current)
+
+	assert.True(t, m.Match("debug.log"), "a replaced Engine keeps serving its Matchers")
+	clone, err := m.Clone()
+	require.NoError(t, err, "Clone still works on a replaced Engine")
+	require.NoError(t, clone.Close())
+
+	require.NoError(t, m.Close())
+	assert.True(t, old.closed, "the last Close shuts the replaced Engine down")
+	_, err = NewMatcherWithEngine(nil, old)
+	assert.ErrorIs(t, err, ErrEngineClosed)
+
+	got, err := reg.Get("v1")
+	require.NoError(t, err)
+	assert.Same(t, current, got)
+	assert.False(t, current.closed)
+}
+
+func TestModuleRegistryUnregister(t *testing.T) {
+	var reg ModuleRegistry
+
+	idle, err := reg.Register("idle", matcherWasm)
+	require.NoError(t, err)
+	require.NoError(t, reg.Unregister("idle"))
+	assert.True(t, idle.closed, "an unused Engine is shut down right away")
+	_, err = reg.Get("idle")
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+
+	busy, err := reg.Register("busy", matcherWasm)
+	require.NoError(t, err)
+	m, err := NewMatcherWithEngine([]string{"*.log"}, busy)
+	require.NoError(t, err)
+	require.NoError(t, reg.Unregister("busy"))
+	assert.False(t, busy.closed)
+	assert.True(t, m.Match("debug.log"))
+	require.NoError(t, m.Close())
+	assert.True(t, busy.closed)
+
+	require.NoError(t, reg.Unregister("missing"), "unknown versions are a no-op")
+}
+
+func TestModuleRegistryRegisterInvalid(t *testing.T) {
+	var reg ModuleRegistry
+	defer func() { _ = reg.Unregister("v1") }()
+
+	eng, err := reg.Register("v1", matcherWasm)
+	require.NoError(t, err)
+
+	_, err = reg.Register("v1", []byte("not wasm"))
+	assert.ErrorContains(t, err, `registering module "v1"`)
+
+	got, err := reg.Get("v1")
+	require.NoError(t, err)
+	assert.Same(t, eng, got, "a failed Register keeps the previous module")
+	assert.False(t, eng.closed)
+}
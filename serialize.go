@@ -0,0 +1,156 @@
+package ignore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// serialMagic and serialVersion head every blob produced by Serialize.
+const (
+	serialMagic   = "GIGN"
+	serialVersion = 1
+)
+
+// Serialize encodes the Matcher's pattern list into a portable byte slice
+// that NewMatcherFromBytes turns back into an equivalent Matcher, so a
+// pattern set can be kept in a cache or database or sent to another process.
+// Only the patterns are encoded: WASM state is rebuilt by recompiling them,
+// and options such as WithCaseInsensitive must be applied again by the
+// caller.
+//
+// The format is the magic "GIGN", a version byte, the pattern count as a
+// uvarint, then each pattern as a uvarint length followed by its UTF-8 bytes.
+func (m *Matcher) Serialize() ([]byte, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	patterns := m.Patterns()
+
+	buf := make([]byte, 0, len(serialMagic)+1+binary.MaxVarintLen64+len(m.patterns)+len(patterns))
+	buf = append(buf, serialMagic...)
+	buf = append(buf, serialVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(patterns)))
+	for _, p := range patterns {
+		buf = binary.AppendUvarint(buf, uint64(len(p)))
+		buf = append(buf, p...)
+	}
+	return buf, nil
+}
+
+// NewMatcherFromBytes compiles the patterns encoded by Matcher.Serialize into
+// a new Matcher. It fails if data is truncated, has trailing bytes, or was
+// written by an unknown format version.
+func NewMatcherFromBytes(data []byte) (*Matcher, error) {
+	patterns, err := decodePatterns(data)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: decoding serialized matcher: %w", err)
+	}
+	return NewMatcher(patterns)
+}
+
+// Checksum returns the SHA-256 digest of the Matcher's pattern list. It is
+// deterministic: the same patterns in the same order always produce the same
+// checksum, across processes and releases, so it can be stored alongside a
+// cached Matcher or serialized blob and compared to decide when to rebuild.
+// Reordering patterns changes the checksum; options do not affect it.
+func (m *Matcher) Checksum() [32]byte {
+	m.mustBeOpen()
+	return sha256.Sum256([]byte(m.patterns))
+}
+
+// MarshalText implements encoding.TextMarshaler. It returns the Matcher's
+// patterns joined by newlines, in .gitignore format, so a *Matcher field can
+// be written by encoding/json, YAML and TOML libraries. A zero Matcher
+// encodes as empty text.
+func (m *Matcher) MarshalText() ([]byte, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	return []byte(strings.ReplaceAll(m.patterns, "\x00", "\n")), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It parses text as a
+// .gitignore file and compiles the result into m, so a *Matcher field can be
+// filled straight from a config file:
+//
+//	var cfg struct {
+//	    Ignore *ignore.Matcher `json:"ignore"`
+//	}
+//	err := json.Unmarshal([]byte(`{"ignore": "*.log\nbuild/"}`), &cfg)
+//
+// If m was already compiled, the new patterns are compiled on the same
+// Engine with the same options and the old matcher is then closed, so its
+// instance is not leaked. On error m is left unchanged. The caller must
+// Close m when done, as with NewMatcher.
+func (m *Matcher) UnmarshalText(text []byte) error {
+	patterns, err := ParseGitignore(bytes.NewReader(text))
+	if err != nil {
+		return err
+	}
+
+	var next *Matcher
+	if m.eng != nil {
+		next, err = m.sibling(strings.Join(patterns, "\x00"))
+	} else {
+		next, err = NewMatcher(patterns)
+	}
+	if err != nil {
+		return err
+	}
+
+	if m.inst != nil {
+		_ = m.Close()
+	}
+	// Collecting next must not close the handle m now owns. m itself is not
+	// given a finalizer: it may be a field inside a larger struct, where
+	// SetFinalizer is not allowed, so the copy must not claim one either.
+	runtime.SetFinalizer(next, nil)
+	next.finalizer = false
+	*m = *next
+	return nil
+}
+
+// decodePatterns parses the format written by Serialize.
+func decodePatterns(data []byte) ([]string, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(serialMagic))
+	if !ok {
+		return nil, errors.New("missing header")
+	}
+	if len(rest) == 0 {
+		return nil, errors.New("truncated header")
+	}
+	if v := rest[0]; v != serialVersion {
+		return nil, fmt.Errorf("unsupported format version %d", v)
+	}
+	rest = rest[1:]
+
+	count, n := binary.Uvarint(rest)
+	if n <= 0 || count > uint64(len(rest)) { // every pattern takes at least one byte
+		return nil, errors.New("invalid pattern count")
+	}
+	rest = rest[n:]
+
+	patterns := make([]string, 0, count)
+	for i := range count {
+		size, n := binary.Uvarint(rest)
+		if n <= 0 || size > uint64(len(rest)-n) {
+			return nil, fmt.Errorf("pattern %d: truncated", i)
+		}
+		p := rest[n : n+int(size)]
+		if !utf8.Valid(p) {
+			return nil, fmt.Errorf("pattern %d: invalid UTF-8", i)
+		}
+		patterns = append(patterns, string(p))
+		rest = rest[n+int(size):]
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d unexpected trailing bytes", len(rest))
+	}
+	return patterns, nil
+}
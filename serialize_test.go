@@ -0,0 +1,179 @@
+package ignore
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// Serialize / NewMatcherFromBytes
+// ---------------------------------------------------------------------------
+
+func TestSerializeRoundTrip(t *testing.T) {
+	patterns := []string{"*.log", "!important.log", "build/", "/docs/**/draft", "ünïcode.txt"}
+
+	m, err := NewMatcher(patterns)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	data, err := m.Serialize()
+	require.NoError(t, err)
+
+	restored, err := NewMatcherFromBytes(data)
+	require.NoError(t, err)
+	defer func() { _ = restored.Close() }()
+
+	assertStringSliceEqual(t, restored.Patterns(), patterns)
+	for _, p := range []string{"debug.log", "important.log", "build/", "docs/a/draft", "ünïcode.txt", "main.go"} {
+		assert.Equal(t, m.Match(p), restored.Match(p), p)
+	}
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	data, err := m.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("GIGN\x01\x00"), data)
+
+	restored, err := NewMatcherFromBytes(data)
+	require.NoError(t, err)
+	defer func() { _ = restored.Close() }()
+	assert.Nil(t, restored.Patterns())
+}
+
+func TestNewMatcherFromBytesInvalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":          "",
+		"bad magic":      "NOPE\x01\x00",
+		"no version":     "GIGN",
+		"future version": "GIGN\x02\x00",
+		"huge count":     "GIGN\x01\xff\xff\xff\xff\x0f",
+		"truncated":      "GIGN\x01\x01\x05*.l",
+		"trailing":       "GIGN\x01\x01\x01*x",
+		"invalid utf-8":  "GIGN\x01\x01\x02\xff\xfe",
+	}
+	for name, data := range tests {
+		_, err := NewMatcherFromBytes([]byte(data))
+		assert.ErrorContains(t, err, "decoding serialized matcher", name)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// MarshalText / UnmarshalText
+// ---------------------------------------------------------------------------
+
+func TestMatcherTextJSONRoundTrip(t *testing.T) {
+	type config struct {
+		Name   string   `json:"name"`
+		Ignore *Matcher `json:"ignore"`
+	}
+	m, err := NewMatcher([]string{"*.log", "!keep.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	data, err := json.Marshal(config{Name: "svc", Ignore: m})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"svc","ignore":"*.log\n!keep.log\nbuild/"}`, string(data))
+
+	var out config
+	require.NoError(t, json.Unmarshal(data, &out))
+	require.NotNil(t, out.Ignore)
+	defer func() { _ = out.Ignore.Close() }()
+
+	assertStringSliceEqual(t, out.Ignore.Patterns(), m.Patterns())
+	assert.True(t, out.Ignore.Match("debug.log"))
+	assert.False(t, out.Ignore.Match("keep.log"))
+	assert.True(t, out.Ignore.MatchDir("build"))
+}
+
+func TestUnmarshalTextParsesGitignore(t *testing.T) {
+	var m Matcher
+	require.NoError(t, m.UnmarshalText([]byte("# comment\r\n*.tmp\n\ndist/\n")))
+	defer func() { _ = m.Close() }()
+
+	assertStringSliceEqual(t, m.Patterns(), []string{"*.tmp", "dist/"})
+	text, err := m.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "*.tmp\ndist/", string(text))
+}
+
+func TestUnmarshalTextReleasesOldInstance(t *testing.T) {
+	eng, err := NewEngineWithWASM(matcherWasm)
+	require.NoError(t, err)
+	defer func() { _ = eng.Shutdown(context.Background()) }()
+
+	m, err := NewMatcherWithEngine([]string{"*.log"}, eng)
+	require.NoError(t, err)
+	require.Zero(t, eng.idleCount())
+
+	require.NoError(t, m.UnmarshalText([]byte("*.tmp")))
+	assert.Same(t, eng, m.eng, "recompiled on the same Engine")
+	assert.Equal(t, 1, eng.idleCount(), "the old instance must be returned to the pool")
+	assert.True(t, m.Match("a.tmp"))
+	assert.False(t, m.Match("a.log"))
+
+	require.NoError(t, m.Close())
+	assert.Equal(t, 2, eng.idleCount())
+}
+
+func TestUnmarshalTextIntoEmbeddedMatcher(t *testing.T) {
+	// A Matcher held by value is not the start of its own allocation, so
+	// Close must not touch its finalizer; SetFinalizer would abort the
+	// process.
+	var cfg struct {
+		Name   string  `json:"name"`
+		Ignore Matcher `json:"ignore"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"svc","ignore":"*.log"}`), &cfg))
+	assert.True(t, cfg.Ignore.Match("debug.log"))
+	require.NoError(t, cfg.Ignore.Close())
+	assert.False(t, cfg.Ignore.Match("debug.log"))
+}
+
+func TestUnmarshalTextTwiceIntoEmbeddedMatcher(t *testing.T) {
+	var cfg struct {
+		Name   string
+		Ignore Matcher
+	}
+	require.NoError(t, cfg.Ignore.UnmarshalText([]byte("*.log")))
+	require.NoError(t, cfg.Ignore.UnmarshalText([]byte("*.tmp")), "the second call closes the first Matcher in place")
+	defer func() { _ = cfg.Ignore.Close() }()
+
+	assert.True(t, cfg.Ignore.Match("a.tmp"))
+	assert.False(t, cfg.Ignore.Match("a.log"))
+}
+
+// ---------------------------------------------------------------------------
+// Checksum
+// ---------------------------------------------------------------------------
+
+func TestChecksum(t *testing.T) {
+	newM := func(patterns ...string) *Matcher {
+		m, err := NewMatcher(patterns)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = m.Close() })
+		return m
+	}
+
+	a := newM("*.log", "build/")
+	assert.Equal(t, a.Checksum(), newM("*.log", "build/").Checksum(), "same patterns, same checksum")
+	assert.NotEqual(t, a.Checksum(), newM("build/", "*.log").Checksum(), "order matters")
+	assert.NotEqual(t, a.Checksum(), newM("*.log").Checksum())
+
+	before := a.Checksum()
+	require.NoError(t, a.AddPatterns([]string{"*.tmp"}))
+	assert.NotEqual(t, before, a.Checksum(), "checksum follows recompilation")
+	require.NoError(t, a.Reset([]string{"*.log", "build/"}))
+	assert.Equal(t, before, a.Checksum())
+
+	// Pinned so an accidental change to the hashed representation is caught.
+	assert.Equal(t, "1567b135600c23db2593e72011fb9081024cf4ae5aee96a321e378db8b7b1eb5", hex.EncodeToString(before[:]))
+}
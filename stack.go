@@ -0,0 +1,162 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreStack holds the .gitignore matchers of the directories on the
+// current path of a tree traversal. Push a directory when entering it and Pop
+// when leaving; Match consults the pushed .gitignore files deepest first, as
+// git does. The first directory pushed is the root that all patterns and
+// matched paths are relative to.
+//
+// Unlike HierarchicalMatcher nothing is loaded up front, which suits callers
+// driving their own walk. The zero value is an empty stack ready to use.
+// NOT safe for concurrent use. Call Close when done.
+type GitignoreStack struct {
+	entries []stackEntry
+}
+
+type stackEntry struct {
+	path string // as passed to Push, cleaned
+	gitignoreLevel
+}
+
+// Push enters dir, loading dir/.gitignore if it exists. dir must be the root
+// or lie below it. A directory without a .gitignore is still pushed so that
+// every Push can be paired with a Pop.
+func (s *GitignoreStack) Push(dir string) error {
+	dir = filepath.Clean(dir)
+	rel := ""
+	if len(s.entries) > 0 {
+		var ok bool
+		if rel, ok = s.relToRoot(dir); !ok {
+			return fmt.Errorf("ignore: GitignoreStack: %s is not under %s", dir, s.entries[0].path)
+		}
+	}
+
+	m, err := NewMatcherFromFile(filepath.Join(dir, gitignoreFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	s.entries = append(s.entries, stackEntry{path: dir, gitignoreLevel: gitignoreLevel{dir: rel, m: m}})
+	return nil
+}
+
+// Pop leaves the most recently pushed directory and closes its Matcher,
+// returning the WASM instance to the pool.
+func (s *GitignoreStack) Pop() error {
+	if len(s.entries) == 0 {
+		return errors.New("ignore: GitignoreStack: Pop on empty stack")
+	}
+	top := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	if top.m != nil {
+		return top.m.Close()
+	}
+	return nil
+}
+
+// Unwind pops every directory that does not contain path. Calling it at the
+// top of a filepath.WalkDir callback, before pushing a newly entered
+// directory, stands in for the post-visit hook WalkDir lacks:
+//
+//	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+//		if err != nil {
+//			return err
+//		}
+//		if err := s.Unwind(path); err != nil {
+//			return err
+//		}
+//		if d.IsDir() {
+//			if path != root && s.MatchDir(path) {
+//				return fs.SkipDir
+//			}
+//			return s.Push(path)
+//		}
+//		if !s.Match(path) {
+//			visit(path)
+//		}
+//		return nil
+//	})
+func (s *GitignoreStack) Unwind(path string) error {
+	path = filepath.Clean(path)
+	for len(s.entries) > 0 {
+		top := s.entries[len(s.entries)-1].path
+		if rel, err := filepath.Rel(top, path); err == nil && rel != "." && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		if err := s.Pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of pushed directories.
+func (s *GitignoreStack) Len() int {
+	return len(s.entries)
+}
+
+// Match reports whether the file at path is ignored by the pushed .gitignore
+// files. path is interpreted like the directories passed to Push. Paths
+// outside the root, and errors, report false; use MatchResult to surface
+// errors.
+func (s *GitignoreStack) Match(path string) bool {
+	ignored, _ := s.MatchResult(path, false)
+	return ignored
+}
+
+// MatchDir is like Match for a directory path.
+func (s *GitignoreStack) MatchDir(path string) bool {
+	ignored, _ := s.MatchResult(path, true)
+	return ignored
+}
+
+// MatchResult reports whether path is ignored and surfaces any error. Only
+// pushed directories that contain path are consulted.
+func (s *GitignoreStack) MatchResult(path string, isDir bool) (bool, error) {
+	rel, ok := s.relToRoot(filepath.Clean(path))
+	if !ok || rel == "" {
+		return false, nil
+	}
+
+	levels := make([]gitignoreLevel, 0, len(s.entries))
+	for _, e := range s.entries {
+		if e.m != nil && (e.dir == "" || strings.HasPrefix(rel, e.dir+"/")) {
+			levels = append(levels, e.gitignoreLevel)
+		}
+	}
+	code, err := matchLevels(levels, rel, isDir)
+	return code == MatchIgnore, err
+}
+
+// relToRoot returns path relative to the root as a slash-separated string,
+// "" for the root itself, reporting false if path is outside the root or the
+// stack is empty.
+func (s *GitignoreStack) relToRoot(path string) (string, bool) {
+	if len(s.entries) == 0 {
+		return "", false
+	}
+	rel, err := filepath.Rel(s.entries[0].path, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Close pops every remaining directory, closing all Matchers. Idempotent.
+func (s *GitignoreStack) Close() error {
+	for len(s.entries) > 0 {
+		_ = s.Pop()
+	}
+	return nil
+}
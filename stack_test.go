@@ -0,0 +1,100 @@
+package ignore
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// GitignoreStack
+// ---------------------------------------------------------------------------
+
+func TestGitignoreStackPushPop(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n",
+		"sub/.gitignore": "!keep.log\n/local/\n",
+		"other/x.go":     "",
+	})
+
+	var s GitignoreStack
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Push(root))
+	assert.True(t, s.Match(filepath.Join(root, "keep.log")))
+
+	require.NoError(t, s.Push(filepath.Join(root, "sub")))
+	assert.False(t, s.Match(filepath.Join(root, "sub", "keep.log")), "deepest .gitignore first")
+	assert.True(t, s.Match(filepath.Join(root, "sub", "debug.log")))
+	assert.True(t, s.MatchDir(filepath.Join(root, "sub", "local")))
+	assert.True(t, s.Match(filepath.Join(root, "keep.log")), "sub/.gitignore only applies below sub")
+
+	require.NoError(t, s.Pop())
+	assert.False(t, s.MatchDir(filepath.Join(root, "local")))
+
+	require.NoError(t, s.Push(filepath.Join(root, "other")), "directory without .gitignore")
+	assert.Equal(t, 2, s.Len())
+	require.NoError(t, s.Pop())
+	require.NoError(t, s.Pop())
+	assert.Error(t, s.Pop(), "Pop on empty stack")
+}
+
+func TestGitignoreStackPushOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	var s GitignoreStack
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Push(filepath.Join(root, "a")))
+	assert.Error(t, s.Push(filepath.Join(root, "b")))
+}
+
+func TestGitignoreStackWalkDir(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":       "*.log\nbuild/\n",
+		"main.go":          "",
+		"debug.log":        "",
+		"build/out.bin":    "",
+		"sub/.gitignore":   "!keep.log\n",
+		"sub/keep.log":     "",
+		"sub/other.log":    "",
+		"sub/lib.go":       "",
+		"sub2/keep.log":    "",
+		"sub2/nested/a.go": "",
+	})
+
+	var s GitignoreStack
+	defer func() { _ = s.Close() }()
+
+	var visited []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := s.Unwind(path); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && s.MatchDir(path) {
+				return fs.SkipDir
+			}
+			return s.Push(path)
+		}
+		if !s.Match(path) {
+			rel, _ := filepath.Rel(root, path)
+			visited = append(visited, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		".gitignore", "main.go",
+		"sub/.gitignore", "sub/keep.log", "sub/lib.go",
+		"sub2/nested/a.go",
+	}, visited)
+}
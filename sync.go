@@ -0,0 +1,99 @@
+package ignore
+
+import "sync"
+
+// SyncMatcher is a Matcher guarded by a mutex, safe for concurrent use by
+// multiple goroutines. Calls are serialized; for throughput under heavy
+// concurrency prefer one Matcher per goroutine (see Clone).
+//
+// There is deliberately no read-write variant: a WASM instance executes one
+// call at a time, so concurrent Match calls on a shared Matcher cannot run in
+// parallel even under a read lock.
+type SyncMatcher struct {
+	mu sync.Mutex
+	m  *Matcher
+}
+
+// NewSyncMatcher compiles patterns like NewMatcherWithOptions and wraps the
+// result in a SyncMatcher.
+func NewSyncMatcher(patterns []string, opts ...Option) (*SyncMatcher, error) {
+	m, err := NewMatcherWithOptions(patterns, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncMatcher{m: m}, nil
+}
+
+// Match is the goroutine-safe equivalent of Matcher.Match.
+func (s *SyncMatcher) Match(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Match(path)
+}
+
+// MatchDir is the goroutine-safe equivalent of Matcher.MatchDir.
+func (s *SyncMatcher) MatchDir(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.MatchDir(path)
+}
+
+// MatchResult is the goroutine-safe equivalent of Matcher.MatchResult.
+func (s *SyncMatcher) MatchResult(path string, isDir bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.MatchResult(path, isDir)
+}
+
+// MatchResultCode is the goroutine-safe equivalent of Matcher.MatchResultCode.
+func (s *SyncMatcher) MatchResultCode(path string, isDir bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.MatchResultCode(path, isDir)
+}
+
+// Filter is the goroutine-safe equivalent of Matcher.Filter.
+func (s *SyncMatcher) Filter(paths []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Filter(paths)
+}
+
+// FilterParallel is the goroutine-safe equivalent of Matcher.FilterParallel.
+// The lock is held for the whole call.
+func (s *SyncMatcher) FilterParallel(paths []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.FilterParallel(paths)
+}
+
+// AddPatterns is the goroutine-safe equivalent of Matcher.AddPatterns.
+func (s *SyncMatcher) AddPatterns(patterns []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.AddPatterns(patterns)
+}
+
+// Reset is the goroutine-safe equivalent of Matcher.Reset.
+func (s *SyncMatcher) Reset(patterns []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Reset(patterns)
+}
+
+// Patterns is the goroutine-safe equivalent of Matcher.Patterns.
+func (s *SyncMatcher) Patterns() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Patterns()
+}
+
+// Close closes the underlying Matcher. Idempotent; after Close the other
+// methods behave as on a closed Matcher: those with an error result return
+// ErrMatcherClosed, the bool ones return false, and Patterns panics. Under
+// WithStrictMode they all panic.
+func (s *SyncMatcher) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Close()
+}
@@ -0,0 +1,61 @@
+package ignore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// SyncMatcher
+// ---------------------------------------------------------------------------
+
+func TestSyncMatcherConcurrent(t *testing.T) {
+	s, err := NewSyncMatcher([]string{"*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				assert.True(t, s.Match(fmt.Sprintf("g%d/%d.log", g, i)))
+				assert.False(t, s.Match(fmt.Sprintf("g%d/%d.go", g, i)))
+				assert.True(t, s.MatchDir("build"))
+
+				kept, err := s.Filter([]string{"a.log", "a.go"})
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"a.go"}, kept)
+			}
+		}(g)
+	}
+
+	wg.Add(1)
+	go func() { // concurrent pattern update must not race with matching
+		defer wg.Done()
+		assert.NoError(t, s.AddPatterns([]string{"*.tmp"}))
+	}()
+	wg.Wait()
+
+	assert.True(t, s.Match("x.tmp"))
+	assert.Equal(t, []string{"*.log", "build/", "*.tmp"}, s.Patterns())
+}
+
+func TestSyncMatcherReset(t *testing.T) {
+	s, err := NewSyncMatcher([]string{"*.log"}, WithCaseInsensitive())
+	require.NoError(t, err)
+	defer func() { _ = s.Close() }()
+
+	require.NoError(t, s.Reset([]string{"*.TMP"}))
+	assert.False(t, s.Match("a.log"))
+	assert.True(t, s.Match("a.tmp"))
+
+	ignored, err := s.MatchResult("a.Tmp", false)
+	require.NoError(t, err)
+	assert.True(t, ignored)
+}
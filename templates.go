@@ -0,0 +1,51 @@
+package ignore
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+// templateFS holds the preset pattern lists used by CommonPatterns. See
+// templates/README.md for where each file comes from.
+//
+//go:embed templates/*.gitignore
+var templateFS embed.FS
+
+// CommonPatterns returns the well-known ignore patterns for the given
+// language or tool identifiers, merged in argument order with comments and
+// duplicates removed (see NormalizePatterns). The lists follow GitHub's
+// gitignore templates. Supported identifiers are "go", "node", "python",
+// "rust", "java", and "docker"; matching is case-insensitive and unknown
+// identifiers contribute nothing.
+//
+//	m, err := ignore.NewMatcher(append(ignore.CommonPatterns("go", "node"), myPatterns...))
+func CommonPatterns(langs ...string) []string {
+	var patterns []string
+	for _, lang := range langs {
+		patterns = append(patterns, templatePatterns(lang)...)
+	}
+	return NormalizePatterns(patterns)
+}
+
+// templatePatterns returns the patterns of the template for lang, or nil.
+func templatePatterns(lang string) []string {
+	entries, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		name, _ := strings.CutSuffix(e.Name(), ".gitignore")
+		if !strings.EqualFold(name, lang) {
+			continue
+		}
+		data, err := templateFS.ReadFile("templates/" + e.Name())
+		if err != nil {
+			return nil
+		}
+		patterns, _ := ParseGitignore(bytes.NewReader(data)) // reading memory cannot fail
+		return patterns
+	}
+	return nil
+}
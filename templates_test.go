@@ -0,0 +1,61 @@
+package ignore
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// CommonPatterns
+// ---------------------------------------------------------------------------
+
+func TestCommonPatterns(t *testing.T) {
+	m, err := NewMatcher(CommonPatterns("go", "Node"))
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.True(t, m.Match("cmd/tool/tool.test"))
+	assert.True(t, m.Match("go.work"))
+	assert.True(t, m.MatchDir("web/node_modules"))
+	assert.True(t, m.Match("npm-debug.log.1"))
+	assert.False(t, m.Match("main.go"))
+	assert.False(t, m.Match("package.json"))
+}
+
+func TestCommonPatternsMerge(t *testing.T) {
+	merged := CommonPatterns("go", "node")
+	for _, p := range merged {
+		assert.False(t, strings.HasPrefix(p, "#"), "comments are dropped: %q", p)
+	}
+	assert.Equal(t, 1, countString(merged, ".env"), "duplicates across templates are collapsed")
+
+	assert.Empty(t, CommonPatterns("cobol"), "unknown identifiers contribute nothing")
+	assert.Empty(t, CommonPatterns())
+}
+
+func TestCommonPatternsTemplatesValid(t *testing.T) {
+	entries, err := fs.ReadDir(templateFS, "templates")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	for _, e := range entries {
+		lang := strings.TrimSuffix(e.Name(), ".gitignore")
+		patterns := CommonPatterns(lang)
+		assert.NotEmpty(t, patterns, lang)
+		assert.Empty(t, ValidatePatterns(patterns), lang)
+	}
+}
+
+func countString(ss []string, s string) int {
+	n := 0
+	for _, v := range ss {
+		if v == s {
+			n++
+		}
+	}
+	return n
+}
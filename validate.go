@@ -0,0 +1,188 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PatternError describes a pattern rejected by ValidatePattern. The WASM
+// module silently skips patterns it cannot compile, so validating first is
+// the only way to learn that a pattern has no effect. Constructors using
+// WithValidation return it as a *PatternError, retrievable with errors.As.
+type PatternError struct {
+	Pattern string // the offending pattern, as given
+	Line    int    // 1-based position in the pattern list; 0 if not known
+	Offset  int    // byte offset in Pattern where the problem starts
+	Reason  string // human-readable description of the problem
+}
+
+func (e *PatternError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("ignore: line %d: invalid pattern %q: %s", e.Line, e.Pattern, e.Reason)
+	}
+	return fmt.Sprintf("ignore: invalid pattern %q: %s", e.Pattern, e.Reason)
+}
+
+// PatternSyntaxError is returned when the WASM module refuses to compile a
+// pattern set (create_matcher reports a build failure). errors.Is matches it
+// against ErrPatternBuild.
+//
+// The module does not say which pattern failed or why, so Line is 0 and
+// Message is generic until it exports its own error. Suspects holds what
+// ValidatePatterns reports for the set. Those Go-side checks are stricter
+// than the module in places (it compiles "src/[broken", for one), so a
+// suspect is a hint, not necessarily the pattern that caused the failure.
+type PatternSyntaxError struct {
+	Patterns []string // the pattern set that failed, as sent to the module
+	Line     int      // 1-based position in Patterns reported by the module; 0 if not known
+	Message  string   // description of the problem
+
+	// Suspects are the ValidatePatterns results for Patterns, with Line
+	// counting positions in Patterns. Patterns are split at NUL bytes on
+	// their way to the module, so an input pattern containing NUL shows up
+	// as several entries there and shifts the lines after it.
+	Suspects []PatternError
+}
+
+func (e *PatternSyntaxError) Error() string {
+	msg := "ignore: failed to compile patterns: " + e.Message
+	if e.Line > 0 {
+		msg = fmt.Sprintf("ignore: failed to compile patterns: line %d %q: %s", e.Line, e.Patterns[e.Line-1], e.Message)
+	}
+	if len(e.Suspects) > 0 {
+		s := e.Suspects[0]
+		msg += fmt.Sprintf(" (validation suspects line %d %q: %s)", s.Line, s.Pattern, s.Reason)
+	}
+	return msg
+}
+
+// Is reports whether target is ErrPatternBuild.
+func (e *PatternSyntaxError) Is(target error) bool { return target == ErrPatternBuild }
+
+// newPatternSyntaxError builds the error for a create_matcher build failure
+// on the NUL-joined pattern set.
+func newPatternSyntaxError(joined string) *PatternSyntaxError {
+	patterns := strings.Split(joined, "\x00")
+	return &PatternSyntaxError{
+		Patterns: patterns,
+		Message:  "rejected by the pattern engine",
+		Suspects: ValidatePatterns(patterns),
+	}
+}
+
+// ValidatePattern checks a single gitignore pattern on the Go side, without
+// compiling it, and returns a *PatternError describing the first problem
+// found. It rejects invalid UTF-8, NUL bytes and line breaks (which would
+// split the pattern in transit), a trailing unescaped backslash, reversed
+// character ranges such as "[z-a]", and unbalanced "{a,b}" alternate groups,
+// all of which the WASM module drops. It also rejects unclosed character
+// classes such as "src/[broken". The module does compile those, treating
+// the "[" as a literal character, so "src/[broken" matches only that exact
+// path; an unclosed "[" is far more often a typo than intended, which is
+// why it is reported. Comments and blank lines are valid.
+func ValidatePattern(pattern string) error {
+	if offset, reason := checkPattern(pattern); reason != "" {
+		return &PatternError{Pattern: pattern, Offset: offset, Reason: reason}
+	}
+	return nil
+}
+
+// ValidatePatterns runs ValidatePattern over every entry of patterns and
+// returns one PatternError per invalid entry, in order, with Line set to its
+// 1-based position. An empty result means every pattern is valid. Like
+// ValidatePattern it makes no WASM calls, so it suits linters and editors
+// reporting all problems in a file at once.
+func ValidatePatterns(patterns []string) []PatternError {
+	var errs []PatternError
+	for i, p := range patterns {
+		if offset, reason := checkPattern(p); reason != "" {
+			errs = append(errs, PatternError{Pattern: p, Line: i + 1, Offset: offset, Reason: reason})
+		}
+	}
+	return errs
+}
+
+// checkPattern returns the byte offset and description of the first problem
+// in pattern, or an empty reason if it is valid.
+func checkPattern(p string) (offset int, reason string) {
+	for i := 0; i < len(p); {
+		r, size := utf8.DecodeRuneInString(p[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i, "invalid UTF-8"
+		}
+		i += size
+	}
+	if i := strings.IndexAny(p, "\x00\r\n"); i >= 0 {
+		return i, "contains a NUL byte or line break"
+	}
+	if strings.HasPrefix(p, "#") {
+		return 0, "" // comment
+	}
+
+	var alternates []int // offsets of the open '{'s
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			if i+1 == len(p) {
+				return i, `dangling escape "\" at end of pattern`
+			}
+			i++
+		case '[':
+			end, off, reason := scanClass(p, i)
+			if reason != "" {
+				return off, reason
+			}
+			i = end
+		case '{':
+			alternates = append(alternates, i)
+		case '}':
+			if len(alternates) == 0 {
+				return i, `unmatched "}"; escape it as "\}" to match it literally`
+			}
+			alternates = alternates[:len(alternates)-1]
+		}
+	}
+	if len(alternates) > 0 {
+		return alternates[len(alternates)-1], `unclosed alternate group "{"`
+	}
+	return 0, ""
+}
+
+// scanClass validates the character class opening at p[start] and returns
+// the offset of its closing ']'. A ']' immediately after the opening '[' (or
+// after a leading '!' or '^') is a literal, as in git.
+func scanClass(p string, start int) (end, offset int, reason string) {
+	i := start + 1
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		i++
+	}
+	first := true
+	for i < len(p) {
+		if p[i] == ']' && !first {
+			return i, 0, ""
+		}
+		first = false
+
+		lo, next := classChar(p, i)
+		if next+1 < len(p) && p[next] == '-' && p[next+1] != ']' {
+			hi, after := classChar(p, next+1)
+			if hi < lo {
+				return 0, i, fmt.Sprintf("invalid character range %q", p[i:after])
+			}
+			next = after
+		}
+		i = next
+	}
+	return 0, start, `unclosed character class "["`
+}
+
+// classChar decodes the possibly escaped character at p[i] inside a class and
+// returns it with the offset just past it.
+func classChar(p string, i int) (rune, int) {
+	if p[i] == '\\' && i+1 < len(p) {
+		i++
+	}
+	r, size := utf8.DecodeRuneInString(p[i:])
+	return r, i + size
+}
@@ -0,0 +1,117 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// ValidatePattern
+// ---------------------------------------------------------------------------
+
+func TestValidatePatternValid(t *testing.T) {
+	for _, p := range []string{
+		"", "# comment [", "*.log", "build/", "!important.log", "/root.txt",
+		"**/node_modules/", `\#file`, `\!bang`, `trailing\ `, "[abc]", "[a-z]*.go",
+		"[!a-c]", "[]]", "[!]x]", `[\]]`, "file.{js,ts}", "file.{js,{ts,tsx}}", `a\}b`, "日本語/*.txt",
+	} {
+		assert.NoError(t, ValidatePattern(p), "pattern %q", p)
+	}
+}
+
+func TestValidatePatternInvalid(t *testing.T) {
+	tests := []struct {
+		pattern string
+		offset  int
+		reason  string
+	}{
+		{"src/[broken", 4, `unclosed character class "["`},
+		{"[z-a].txt", 1, `invalid character range "z-a"`},
+		{"a}b", 1, `unmatched "}"; escape it as "\}" to match it literally`},
+		{"x{a,b}}", 6, `unmatched "}"; escape it as "\}" to match it literally`},
+		{"file.{js,ts", 5, `unclosed alternate group "{"`},
+		{`dir\`, 3, `dangling escape "\" at end of pattern`},
+		{"a\x00b", 1, "contains a NUL byte or line break"},
+		{"a\nb", 1, "contains a NUL byte or line break"},
+		{"bad\xffutf8", 3, "invalid UTF-8"},
+	}
+	for _, tt := range tests {
+		err := ValidatePattern(tt.pattern)
+		var perr *PatternError
+		if !assert.True(t, errors.As(err, &perr), "pattern %q: got %v", tt.pattern, err) {
+			continue
+		}
+		assert.Equal(t, tt.pattern, perr.Pattern)
+		assert.Equal(t, tt.reason, perr.Reason, "pattern %q", tt.pattern)
+		assert.Equal(t, tt.offset, perr.Offset, "pattern %q", tt.pattern)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// ValidatePatterns
+// ---------------------------------------------------------------------------
+
+func TestValidatePatterns(t *testing.T) {
+	errs := ValidatePatterns([]string{"*.log", "src/[broken", "# comment", "[z-a]", "build/"})
+	require.Len(t, errs, 2, "all errors must be reported, not just the first")
+
+	assert.Equal(t, "src/[broken", errs[0].Pattern)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, `ignore: line 2: invalid pattern "src/[broken": unclosed character class "["`, errs[0].Error())
+
+	assert.Equal(t, "[z-a]", errs[1].Pattern)
+	assert.Equal(t, 4, errs[1].Line)
+
+	assert.Empty(t, ValidatePatterns([]string{"*.log", "build/"}))
+	assert.Empty(t, ValidatePatterns(nil))
+}
+
+// ---------------------------------------------------------------------------
+// WithValidation
+// ---------------------------------------------------------------------------
+
+func TestWithValidation(t *testing.T) {
+	_, err := NewMatcherWithOptions([]string{"*.log", "src/[broken"}, WithValidation())
+	var perr *PatternError
+	require.ErrorAs(t, fmt.Errorf("loading config: %w", err), &perr, "must survive wrapping")
+	assert.Equal(t, "src/[broken", perr.Pattern)
+	assert.Equal(t, 2, perr.Line)
+
+	m, err := NewMatcherWithOptions([]string{"*.log", "[a-z].tmp"}, WithValidation())
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("x.tmp"))
+}
+
+func TestUnclosedClassMatchesLiterally(t *testing.T) {
+	// ValidatePattern flags this as a likely typo, but the engine accepts it.
+	m, err := NewMatcher([]string{"src/[broken"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+	assert.True(t, m.Match("src/[broken"))
+	assert.False(t, m.Match("src/b"))
+}
+
+func TestPatternSyntaxError(t *testing.T) {
+	err := error(newPatternSyntaxError("*.log\x00src/[broken\x00build/"))
+	require.ErrorIs(t, err, ErrPatternBuild)
+	var pse *PatternSyntaxError
+	require.ErrorAs(t, err, &pse)
+	assert.Equal(t, []string{"*.log", "src/[broken", "build/"}, pse.Patterns)
+	assert.Zero(t, pse.Line, "the module does not report a line; validation must not claim one")
+	require.Len(t, pse.Suspects, 1)
+	assert.Equal(t, 2, pse.Suspects[0].Line)
+	assert.Equal(t, "src/[broken", pse.Suspects[0].Pattern)
+	assert.Contains(t, err.Error(), `ignore: failed to compile patterns: rejected by the pattern engine (validation suspects line 2 "src/[broken": `)
+
+	// Nothing the Go-side checks can point at.
+	err = newPatternSyntaxError("*.log")
+	require.ErrorAs(t, err, &pse)
+	assert.Zero(t, pse.Line)
+	assert.Empty(t, pse.Suspects)
+	assert.Equal(t, "ignore: failed to compile patterns: rejected by the pattern engine", err.Error())
+}
@@ -0,0 +1,118 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WalkDir walks the file tree rooted at root like filepath.WalkDir, but skips
+// everything m ignores. Ignored directories are pruned without being read, so
+// their contents are never evaluated, and fn is not called for ignored files.
+// Paths are matched relative to root, with "/" separators; fn receives the
+// same paths filepath.WalkDir would. root itself is always visited.
+//
+// Errors reported by the walk are passed to fn unchanged. An error from the
+// Matcher aborts the walk and is returned.
+func WalkDir(root string, m *Matcher, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return fn(path, d, err)
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		ignored, err := m.MatchResult(filepath.ToSlash(rel), d.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return skipResult(d)
+		}
+		return fn(path, d, nil)
+	})
+}
+
+// WalkFS is like WalkDir but walks root within fsys using fs.WalkDir, so it
+// works with os.DirFS, embed.FS, fstest.MapFS, and archive filesystems. Paths
+// are matched relative to root; fsys paths are already slash-separated.
+func WalkFS(fsys fs.FS, root string, m *Matcher, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return fn(path, d, err)
+		}
+
+		ignored, err := m.MatchResult(fsRel(root, path), d.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return skipResult(d)
+		}
+		return fn(path, d, nil)
+	})
+}
+
+// FilterFS returns the slash-separated fsys paths of every non-directory
+// entry under root that m does not ignore, in lexical order. Directories are
+// matched with MatchDir semantics and pruned when ignored, so patterns such as
+// "build/" never have their contents enumerated.
+//
+// Errors from fsys, such as an unreadable directory, do not stop the walk:
+// they are joined and returned alongside the paths collected so far. An error
+// from the Matcher aborts the walk and is returned the same way.
+func FilterFS(fsys fs.FS, root string, m *Matcher) ([]string, error) {
+	var (
+		kept   []string
+		fsErrs []error
+	)
+	err := WalkFS(fsys, root, m, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fsErrs = append(fsErrs, err)
+			return nil
+		}
+		if !d.IsDir() {
+			kept = append(kept, path)
+		}
+		return nil
+	})
+	return kept, errors.Join(append(fsErrs, err)...)
+}
+
+// FilterDirEntries returns the entries of dir, as listed by os.ReadDir or
+// fs.ReadDir, that m does not ignore, in their original order. Each entry is
+// matched as path.Join(dir, entry.Name()), so dir must be relative to the
+// Matcher's root; an OS path is converted with filepath.ToSlash, and "" or
+// "." mean the root itself. Directory entries are matched with MatchDir
+// semantics. All names are checked in one batch_filter round-trip:
+//
+//	entries, err := os.ReadDir(filepath.Join(root, dir))
+//	entries, err = ignore.FilterDirEntries(m, entries, dir)
+func FilterDirEntries(m *Matcher, entries []fs.DirEntry, dir string) ([]fs.DirEntry, error) {
+	dir = filepath.ToSlash(dir)
+	return FilterFunc(m, entries,
+		func(e fs.DirEntry) string { return path.Join(dir, e.Name()) },
+		fs.DirEntry.IsDir)
+}
+
+// fsRel returns the fs.FS path relative to root, which must be path itself
+// or one of its ancestors.
+func fsRel(root, path string) string {
+	if root == "." {
+		return path
+	}
+	return strings.TrimPrefix(path, root+"/")
+}
+
+// skipResult is what a walk callback returns for an ignored entry: fs.SkipDir
+// to prune a directory, nil to simply pass over a file.
+func skipResult(d fs.DirEntry) error {
+	if d.IsDir() {
+		return fs.SkipDir
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package ignore
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/walkfs
+var walkFSTestdata embed.FS
+
+// writeTree creates the given slash-separated files under a fresh temp dir.
+func writeTree(t *testing.T, files ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	return root
+}
+
+// ---------------------------------------------------------------------------
+// WalkDir
+// ---------------------------------------------------------------------------
+
+func TestWalkDir(t *testing.T) {
+	root := writeTree(t,
+		"main.go",
+		"debug.log",
+		"keep.log",
+		"build/out.bin",
+		"build/keep.log",
+		"src/lib.go",
+		"src/build/gen.go",
+	)
+
+	m, err := NewMatcher([]string{"*.log", "!keep.log", "/build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	var visited []string
+	err = WalkDir(root, m, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		rel, _ := filepath.Rel(root, path)
+		visited = append(visited, filepath.ToSlash(rel))
+		return nil
+	})
+	require.NoError(t, err)
+
+	// build/keep.log is whitelisted but its parent is pruned, as in git.
+	assert.Equal(t, []string{".", "keep.log", "main.go", "src", "src/build", "src/build/gen.go", "src/lib.go"}, visited)
+}
+
+func TestWalkDirPropagatesCallbackSkipDir(t *testing.T) {
+	root := writeTree(t, "a/x.go", "b/y.go")
+
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	var visited []string
+	err = WalkDir(root, m, func(path string, d fs.DirEntry, err error) error {
+		visited = append(visited, filepath.Base(path))
+		if d.IsDir() && d.Name() == "a" {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Base(root), "a", "b", "y.go"}, visited)
+}
+
+// ---------------------------------------------------------------------------
+// WalkFS
+// ---------------------------------------------------------------------------
+
+// collectWalkFS runs WalkFS and returns the visited paths.
+func collectWalkFS(t *testing.T, fsys fs.FS, root string, m *Matcher) []string {
+	t.Helper()
+	var visited []string
+	err := WalkFS(fsys, root, m, func(path string, d fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		visited = append(visited, path)
+		return nil
+	})
+	require.NoError(t, err)
+	return visited
+}
+
+func TestWalkFSMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":          {},
+		"debug.log":        {},
+		"keep.log":         {},
+		"build/out.bin":    {},
+		"src/lib.go":       {},
+		"src/build/gen.go": {},
+	}
+
+	m, err := NewMatcher([]string{"*.log", "!keep.log", "/build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	assert.Equal(t,
+		[]string{".", "keep.log", "main.go", "src", "src/build", "src/build/gen.go", "src/lib.go"},
+		collectWalkFS(t, fsys, ".", m))
+}
+
+func TestWalkFSEmbedSubdirRoot(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "!keep.log", "/build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	// Patterns are anchored at root, so /build/ prunes only the top-level dir.
+	assert.Equal(t, []string{
+		"testdata/walkfs",
+		"testdata/walkfs/keep.log",
+		"testdata/walkfs/main.go",
+		"testdata/walkfs/src",
+		"testdata/walkfs/src/build",
+		"testdata/walkfs/src/build/gen.go",
+		"testdata/walkfs/src/lib.go",
+	}, collectWalkFS(t, walkFSTestdata, "testdata/walkfs", m))
+}
+
+func TestWalkFSErrors(t *testing.T) {
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	// Walker errors reach fn.
+	var walkErr error
+	err = WalkFS(fstest.MapFS{}, "missing", m, func(path string, d fs.DirEntry, err error) error {
+		walkErr = err
+		return err
+	})
+	assert.ErrorIs(t, walkErr, fs.ErrNotExist)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// Errors from fn abort the walk and are returned.
+	stop := errors.New("stop")
+	err = WalkFS(fstest.MapFS{"a.go": {}}, ".", m, func(path string, d fs.DirEntry, err error) error {
+		if path == "a.go" {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+}
+
+// ---------------------------------------------------------------------------
+// FilterFS
+// ---------------------------------------------------------------------------
+
+func TestFilterFS(t *testing.T) {
+	m, err := NewMatcher([]string{"*.log", "!keep.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	got, err := FilterFS(walkFSTestdata, "testdata/walkfs", m)
+	require.NoError(t, err)
+	assertStringSliceEqual(t, got, []string{
+		"testdata/walkfs/keep.log",
+		"testdata/walkfs/main.go",
+		"testdata/walkfs/src/lib.go",
+	})
+}
+
+func TestFilterFSDirectoryOnlyPattern(t *testing.T) {
+	fsys := fstest.MapFS{
+		"out":          {}, // a file named like the ignored directory
+		"sub/out/a.go": {},
+		"sub/b.go":     {},
+	}
+
+	m, err := NewMatcher([]string{"out/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	got, err := FilterFS(fsys, ".", m)
+	require.NoError(t, err)
+	assertStringSliceEqual(t, got, []string{"out", "sub/b.go"})
+}
+
+func TestFilterFSErrors(t *testing.T) {
+	m, err := NewMatcher(nil)
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	got, err := FilterFS(fstest.MapFS{"a.go": {}}, "missing", m)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, got)
+
+	// An unreadable directory is reported, and the rest of the tree is kept.
+	fsys := unreadableDirFS{
+		MapFS: fstest.MapFS{"a.go": {}, "locked/b.go": {}, "z.go": {}},
+		dir:   "locked",
+	}
+	got, err = FilterFS(fsys, ".", m)
+	assert.ErrorIs(t, err, fs.ErrPermission)
+	assertStringSliceEqual(t, got, []string{"a.go", "z.go"})
+}
+
+// ---------------------------------------------------------------------------
+// FilterDirEntries
+// ---------------------------------------------------------------------------
+
+func TestFilterDirEntries(t *testing.T) {
+	root := writeTree(t, "src/debug.log", "src/main.go", "src/build/out.bin", "src/keep/x.go", "debug.log")
+
+	m, err := NewMatcher([]string{"src/*.log", "build/"})
+	require.NoError(t, err)
+	defer func() { _ = m.Close() }()
+
+	entries, err := os.ReadDir(filepath.Join(root, "src"))
+	require.NoError(t, err)
+	got, err := FilterDirEntries(m, entries, filepath.FromSlash("src"))
+	require.NoError(t, err)
+
+	names := make([]string, len(got))
+	for i, e := range got {
+		names[i] = e.Name()
+	}
+	assertStringSliceEqual(t, names, []string{"keep", "main.go"})
+
+	// At the root "src/*.log" does not apply to debug.log.
+	entries, err = os.ReadDir(root)
+	require.NoError(t, err)
+	got, err = FilterDirEntries(m, entries, ".")
+	require.NoError(t, err)
+	assert.Len(t, got, len(entries))
+}
+
+// unreadableDirFS fails ReadDir on dir with fs.ErrPermission.
+type unreadableDirFS struct {
+	fstest.MapFS
+	dir string
+}
+
+func (f unreadableDirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadDir(name)
+}